@@ -1,6 +1,7 @@
 package charm
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -17,6 +18,7 @@ func ParseEof(str string, first State) error {
 
 type Parser struct {
 	in  io.RuneReader
+	ctx context.Context
 	err error
 	ofs int
 }
@@ -25,6 +27,16 @@ func MakeParser(in io.RuneReader) Parser {
 	return Parser{in: in}
 }
 
+// a parser which stops with the context's error
+// if the context is canceled ( or times out ) while parsing.
+// the context is checked every few runes, rather than on every rune.
+func MakeContextParser(ctx context.Context, in io.RuneReader) Parser {
+	return Parser{in: in, ctx: ctx}
+}
+
+// how many runes to read between checks of the parser's context.
+const checkInterval = 1024
+
 func (p *Parser) Error() error {
 	return p.err
 }
@@ -83,7 +95,10 @@ func (p *Parser) ParseEof(first State) (err error) {
 func (p *Parser) Parse(first State) (ret State, err error) {
 	try := first
 	for {
-		if r, _, e := p.in.ReadRune(); e != nil {
+		if e := p.checkContext(); e != nil {
+			err = e // ex. context.Canceled
+			break
+		} else if r, _, e := p.in.ReadRune(); e != nil {
 			err = e // ex. io.Eof
 			break
 		} else if next := try.NewRune(r); next == nil {
@@ -100,3 +115,11 @@ func (p *Parser) Parse(first State) (ret State, err error) {
 	ret = try
 	return
 }
+
+// returns the context's error once every checkInterval runes.
+func (p *Parser) checkContext() (err error) {
+	if p.ctx != nil && p.ofs%checkInterval == 0 {
+		err = p.ctx.Err()
+	}
+	return
+}
@@ -0,0 +1,38 @@
+package charmed
+
+import (
+	"strings"
+
+	"github.com/ionous/tell/charm"
+	"github.com/ionous/tell/runes"
+)
+
+// Heredoc holds a multiline string along with the details of its header.
+// for example, a heredoc starting with ```go <<<END
+// has the Lang "go", the Tag "END", and the Style '`'.
+type Heredoc struct {
+	Lang  string // an optional filetype, the first word after the opening quotes.
+	Tag   string // an optional custom closing tag; empty when the heredoc closes with quotes.
+	Style rune   // the opening quote: QuoteRaw, QuoteSingle, QuoteDouble, or QuotePipe.
+	Text  string
+}
+
+// like DecodeQuote, but if the string turns out to be a heredoc
+// records the details of its header into doc.
+// ( the text is written to out; doc.Style remains zero for other strings. )
+func DecodeHeredoc(q rune, out *strings.Builder, doc *Heredoc) (ret charm.State, okay bool) {
+	switch q {
+	case runes.QuoteDouble:
+		ret, okay = scanRemainingString(out, q, AllowHere|AllowEscapes|FoldLines, doc), true
+	case runes.QuoteSingle:
+		ret, okay = scanRemainingString(out, q, AllowHere|FoldLines, doc), true
+	case runes.QuoteRaw:
+		ret, okay = scanRemainingString(out, q, AllowHere, doc), true
+	case runes.QuotePipe:
+		if doc != nil {
+			doc.Style = q
+		}
+		ret, okay = DecodePipe(out), true
+	}
+	return
+}
@@ -1,7 +1,9 @@
 package charmed
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"math/bits"
 	"strconv"
 	"strings"
@@ -17,12 +19,17 @@ const (
 	modeInt
 	modeHex
 	modeFloat
+	modeOctal
+	modeBinary
 )
 
 // return a state which reads until the end of string, returns error if finished incorrectly
 type NumParser struct {
 	runes strings.Builder
 	mode  modeType
+	// allow signed infinity: +inf and -inf
+	// ( unsigned inf and nan are words, and are left to the caller )
+	AllowInf bool
 }
 
 func (*NumParser) String() string {
@@ -35,15 +42,16 @@ func (p *NumParser) accept(q rune, s charm.State) charm.State {
 	return s
 }
 
-// returns int64 or float64
+// returns int, uint ( for hex, octal, and binary ), or float64
+// returns an error for numbers which don't fit those types.
 func (p *NumParser) GetNumber() (ret any, err error) {
 	switch s := p.runes.String(); p.mode {
 	case modeInt:
-		ret = fromInt(s)
-	case modeHex:
-		ret = fromHex(s)
+		ret, err = fromInt(s)
+	case modeHex, modeOctal, modeBinary:
+		ret, err = fromPrefix(s)
 	case modeFloat:
-		ret = fromFloat(s)
+		ret, err = fromFloat(s)
 	default:
 		err = fmt.Errorf("unknown number: '%v' is %v", s, p.mode)
 	}
@@ -53,18 +61,76 @@ func (p *NumParser) GetNumber() (ret any, err error) {
 // helper to turn a string into a value
 func (p *NumParser) GetFloat() (ret float64, err error) {
 	switch s := p.runes.String(); p.mode {
-	case modeInt:
-		ret = float64(fromInt(s))
-	case modeHex:
-		ret = float64(fromHex(s))
-	case modeFloat:
-		ret = fromFloat(s)
+	case modeInt, modeFloat:
+		ret, err = fromFloat(s)
+	case modeHex, modeOctal, modeBinary:
+		var u uint
+		u, err = fromPrefix(s)
+		ret = float64(u)
 	default:
 		err = fmt.Errorf("unknown number: '%v' is %v", s, p.mode)
 	}
 	return
 }
 
+// like GetNumber, except that numbers which don't fit
+// are returned as *big.Int or *big.Float.
+func (p *NumParser) GetBigNumber() (ret any, err error) {
+	if ret, err = p.GetNumber(); errors.Is(err, strconv.ErrRange) {
+		ret, err = p.getBig(false)
+	}
+	return
+}
+
+// like GetFloat, except that numbers which don't fit are returned as *big.Float.
+func (p *NumParser) GetBigFloat() (ret any, err error) {
+	if ret, err = p.GetFloat(); errors.Is(err, strconv.ErrRange) {
+		ret, err = p.getBig(true)
+	}
+	return
+}
+
+// return the text of the number exactly as it was written.
+func (p *NumParser) GetText() (ret Number, err error) {
+	switch s := p.runes.String(); p.mode {
+	case modeInt, modeHex, modeFloat, modeOctal, modeBinary:
+		ret = Number(s)
+	default:
+		err = fmt.Errorf("unknown number: '%v' is %v", s, p.mode)
+	}
+	return
+}
+
+func (p *NumParser) getBig(useFloats bool) (ret any, err error) {
+	switch s := p.runes.String(); {
+	case p.mode == modeFloat || (useFloats && p.mode == modeInt):
+		// roughly four bits for every decimal digit, and at least a float64's worth.
+		// ( base 0 handles the prefix of hex floats, and underscores )
+		prec := max(uint(len(s))*4, 64)
+		if f, _, e := big.ParseFloat(s, 0, prec, big.ToNearestEven); e != nil {
+			err = e
+		} else {
+			ret = f
+		}
+	default:
+		// base 0 handles prefixes and underscores; but it treats a leading zero as octal.
+		base := 10
+		if p.mode != modeInt {
+			base = 0
+		} else {
+			s = strings.ReplaceAll(s, "_", "")
+		}
+		if i, ok := new(big.Int).SetString(s, base); !ok {
+			err = fmt.Errorf("invalid number %q", s)
+		} else if useFloats {
+			ret = new(big.Float).SetInt(i)
+		} else {
+			ret = i
+		}
+	}
+	return
+}
+
 // return a state capable of digit parsing.
 // note: this doesn't support leading with just a "."
 func (p *NumParser) Decode() charm.State {
@@ -75,24 +141,27 @@ func (p *NumParser) Decode() charm.State {
 				if runes.IsNumber(r) {
 					p.mode = modeInt
 					ret = p.accept(r, charm.Statement("num plus", p.leadingDigit))
+				} else if r == 'i' && p.AllowInf {
+					ret = p.accept(r, charm.Step(StringMatch("nf"), charm.Statement("inf", func(r rune) charm.State {
+						p.runes.WriteString("nf")
+						p.mode = modeFloat
+						return nil
+					})))
 				}
 				return
 			}))
 		case '0':
-			// 0 can standalone; but, it might be followed by a hex qualifier.
+			// 0 can standalone; but, it might be followed by a prefix.
 			p.mode = modeInt
-			ret = p.accept(r, charm.Statement("hex check", func(r rune) (ret charm.State) {
-				// https://golang.org/ref/spec#hex_literal
-				switch {
-				case r == 'x' || r == 'X':
-					p.mode = modePending
-					ret = p.accept(r, charm.Statement("hex parse", func(r rune) (ret charm.State) {
-						if runes.IsHex(r) {
-							p.mode = modeHex
-							ret = p.accept(r, charm.Statement("num hex", p.hexDigits))
-						}
-						return
-					}))
+			ret = p.accept(r, charm.Statement("prefix check", func(r rune) (ret charm.State) {
+				// https://golang.org/ref/spec#integer_literals
+				switch r {
+				case 'x', 'X':
+					ret = p.prefix(r, modeHex, runes.IsHex, p.hexFraction)
+				case 'o', 'O':
+					ret = p.prefix(r, modeOctal, isOctal, nil)
+				case 'b', 'B':
+					ret = p.prefix(r, modeBinary, isBinary, nil)
 				default:
 					// delegate to number and dot checking...
 					// in a statecharmed, it would be a super-state, and
@@ -112,47 +181,172 @@ func (p *NumParser) Decode() charm.State {
 	})
 }
 
-// a string of numbers, possibly followed by a decimal or exponent separator.
-// note: golang numbers can end in a pure ".", this does not allow that.
-func (p *NumParser) leadingDigit(r rune) (ret charm.State) {
-	switch {
-	case runes.IsNumber(r):
-		ret = p.accept(r, charm.Statement("leading dig", p.leadingDigit))
-	case r == '.':
-		p.mode = modePending
-		ret = p.accept(r, charm.Statement("decimal", func(r rune) (ret charm.State) {
-			if runes.IsNumber(r) {
-				p.mode = modeFloat
-				ret = p.accept(r, charm.Statement("decimal digits", p.leadingDigit))
+// a fast path for simple decimal numbers:
+// an optional sign, digits, an optional fraction, and an optional exponent.
+// on success, records the number and returns its length in bytes;
+// the parser can then be used as if Decode() had read the same runes.
+// returns zero for anything more complicated ( ex. hex values )
+// or anything that Decode() might have parsed differently;
+// in which case, the caller should use Decode() instead.
+func (p *NumParser) ScanDecimal(src []byte) (ret int) {
+	i, mode := 0, modeInt
+	if i < len(src) && (src[i] == '-' || src[i] == '+') {
+		i++
+	}
+	if digits := countDigits(src[i:]); digits == 0 {
+		i = 0
+	} else {
+		i += digits
+		if i < len(src) && src[i] == '.' {
+			if digits := countDigits(src[i+1:]); digits == 0 {
+				i = 0
 			} else {
-				ret = p.tryExponent(r) // delegate to exponent checking,,,
+				i, mode = i+1+digits, modeFloat
 			}
-			return
-		}))
-	default:
-		ret = p.tryExponent(r) // delegate to exponent checking,,,
+		}
+		if i > 0 && i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+			exp := i + 1
+			if exp < len(src) && (src[exp] == '-' || src[exp] == '+') {
+				exp++
+			}
+			if digits := countDigits(src[exp:]); digits == 0 {
+				i = 0
+			} else {
+				i, mode = exp+digits, modeFloat
+			}
+		}
+	}
+	// the number has to end cleanly
+	if i > 0 && (i == len(src) || isDecimalEnd(src[i])) {
+		p.runes.Write(src[:i])
+		p.mode = mode
+		ret = i
+	}
+	return
+}
+
+func countDigits(src []byte) (ret int) {
+	for ret < len(src) && runes.IsNumber(rune(src[ret])) {
+		ret++
+	}
+	return
+}
+
+// runes which can follow a number
+// without affecting how Decode() would have parsed it.
+func isDecimalEnd(b byte) (okay bool) {
+	switch b {
+	case runes.Space, runes.Newline, runes.ArraySeparator, runes.ArrayClose:
+		okay = true
 	}
 	return
 }
 
+// a string of numbers, possibly followed by a decimal or exponent separator.
+// note: golang numbers can end in a pure ".", this does not allow that.
+func (p *NumParser) leadingDigit(r rune) (ret charm.State) {
+	return p.digits(r, runes.IsNumber, func(r rune) (ret charm.State) {
+		if r != '.' {
+			ret = p.tryExponent(r) // delegate to exponent checking,,,
+		} else {
+			p.mode = modePending
+			ret = p.accept(r, charm.Statement("decimal", func(r rune) (ret charm.State) {
+				if runes.IsNumber(r) {
+					p.mode = modeFloat
+					ret = p.accept(r, charm.Statement("decimal digits", p.leadingDigit))
+				} else {
+					ret = p.tryExponent(r) // delegate to exponent checking,,,
+				}
+				return
+			}))
+		}
+		return
+	})
+}
+
 // https://golang.org/ref/spec#exponent
 // exponent  = ( "e" | "E" ) [ "+" | "-" ] decimals
 func (p *NumParser) tryExponent(r rune) (ret charm.State) {
-	switch {
-	case r == 'e' || r == 'E':
+	if r == 'e' || r == 'E' {
+		ret = p.exponent(r)
+	}
+	return
+}
+
+// the exponent of decimal and hex floats
+// the passed rune is the exponent marker.
+func (p *NumParser) exponent(r rune) charm.State {
+	p.mode = modePending
+	return p.accept(r, charm.Statement("exp", func(r rune) (ret charm.State) {
+		switch {
+		case runes.IsNumber(r):
+			p.mode = modeFloat
+			ret = p.accept(r, charm.Statement("exp decimal", p.decimals))
+		case r == '+' || r == '-':
+			ret = p.accept(r, charm.Statement("exp power", func(r rune) (ret charm.State) {
+				if runes.IsNumber(r) {
+					p.mode = modeFloat
+					ret = p.accept(r, charm.Statement("exp num", p.decimals))
+				}
+				return
+			}))
+		}
+		return
+	}))
+}
+
+// a chain of decimal digits 0-9
+func (p *NumParser) decimals(r rune) (ret charm.State) {
+	return p.digits(r, runes.IsNumber, nil)
+}
+
+// a base prefix ( ex. the x of 0x )
+// which must be followed by at least one digit.
+// the passed "after" handles anything following the digits.
+func (p *NumParser) prefix(r rune, mode modeType, isDigit func(rune) bool, after func(rune) charm.State) charm.State {
+	p.mode = modePending
+	return p.accept(r, charm.Statement("prefix", func(r rune) (ret charm.State) {
+		// an underscore can separate the prefix from the first digit
+		if r == '_' {
+			ret = p.accept(r, charm.Statement("prefix separator", func(r rune) (ret charm.State) {
+				if isDigit(r) {
+					p.mode = mode
+					ret = p.accept(r, charm.Statement("prefixed", func(r rune) charm.State {
+						return p.digits(r, isDigit, after)
+					}))
+				}
+				return
+			}))
+		} else if isDigit(r) {
+			p.mode = mode
+			ret = p.accept(r, charm.Statement("prefixed", func(r rune) charm.State {
+				return p.digits(r, isDigit, after)
+			}))
+		}
+		return
+	}))
+}
+
+// after the digits of a hex number:
+// an optional fraction, followed by a required exponent, makes a hex float.
+// ex. 0x1.8p1
+func (p *NumParser) hexFraction(r rune) (ret charm.State) {
+	switch r {
+	case 'p', 'P':
+		ret = p.exponent(r)
+	case '.':
 		p.mode = modePending
-		ret = p.accept(r, charm.Statement("exp", func(r rune) (ret charm.State) {
-			switch {
-			case runes.IsNumber(r):
-				p.mode = modeFloat
-				ret = p.accept(r, charm.Statement("exp decimal", p.decimals))
-			case r == '+' || r == '-':
-				ret = p.accept(r, charm.Statement("exp power", func(r rune) (ret charm.State) {
-					if runes.IsNumber(r) {
-						p.mode = modeFloat
-						ret = p.accept(r, charm.Statement("exp num", p.decimals))
-					}
-					return
+		ret = p.accept(r, charm.Statement("hex fraction", func(r rune) (ret charm.State) {
+			if r == 'p' || r == 'P' {
+				ret = p.exponent(r)
+			} else if runes.IsHex(r) {
+				ret = p.accept(r, charm.Statement("hex fraction digits", func(r rune) charm.State {
+					return p.digits(r, runes.IsHex, func(r rune) (ret charm.State) {
+						if r == 'p' || r == 'P' {
+							ret = p.exponent(r)
+						}
+						return
+					})
 				}))
 			}
 			return
@@ -161,69 +355,64 @@ func (p *NumParser) tryExponent(r rune) (ret charm.State) {
 	return
 }
 
-// a chain of decimal digits 0-9
-func (p *NumParser) decimals(r rune) (ret charm.State) {
-	if runes.IsNumber(r) {
-		ret = p.accept(r, charm.Statement("decimals", p.decimals))
+// a chain of digits, optionally separated by single underscores.
+// ( the digit preceding the passed rune has already been accepted. )
+// calls "after" for anything else; a nil "after" ends the number.
+func (p *NumParser) digits(r rune, isDigit func(rune) bool, after func(rune) charm.State) (ret charm.State) {
+	next := charm.Statement("digits", func(r rune) charm.State {
+		return p.digits(r, isDigit, after)
+	})
+	switch {
+	case isDigit(r):
+		ret = p.accept(r, next)
+	case r == '_':
+		// the number isn't valid until there's another digit
+		mode := p.mode
+		p.mode = modePending
+		ret = p.accept(r, charm.Statement("separator", func(r rune) (ret charm.State) {
+			if isDigit(r) {
+				p.mode = mode
+				ret = p.accept(r, next)
+			}
+			return
+		}))
+	case after != nil:
+		ret = after(r)
 	}
 	return
 }
 
-// a chain of hex digits 0-9, a-f
-func (p *NumParser) hexDigits(r rune) (ret charm.State) {
-	if runes.IsHex(r) {
-		ret = p.accept(r, charm.Statement("hexDigits", p.hexDigits))
-	}
-	return
+func isOctal(r rune) bool {
+	return r >= '0' && r <= '7'
 }
 
-func fromInt(s string) (ret int) {
-	s, negate := unary(s)
-	if i, e := strconv.ParseInt(s, 10, bits.UintSize); e != nil {
-		panic(e)
-	} else if negate {
-		ret = -int(i)
-	} else {
-		ret = int(i)
-	}
-	return
+func isBinary(r rune) bool {
+	return r == '0' || r == '1'
 }
 
-func fromHex(s string) (ret uint) {
-	// hex string - chops out the 0x qualifier
-	if i, e := strconv.ParseUint(s[2:], 16, bits.UintSize); e != nil {
-		panic(e)
+// decimal numbers; unlike go, a leading zero ( ex. 0600 ) doesn't mean octal.
+func fromInt(s string) (ret int, err error) {
+	// note: strconv's base 10 parser handles leading signs, but not underscores.
+	if i, e := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 10, bits.UintSize); e != nil {
+		err = e
 	} else {
-		ret = uint(i) // no negative for hex.
+		ret = int(i)
 	}
 	return
 }
 
-func fromFloat(s string) (ret float64) {
-	s, negate := unary(s)
-	if f, e := strconv.ParseFloat(s, 64); e != nil {
-		panic(e)
-	} else if negate {
-		ret = -f
+// hex, octal, and binary numbers
+func fromPrefix(s string) (ret uint, err error) {
+	// base 0 uses the prefix to determine the base, and allows underscores.
+	if i, e := strconv.ParseUint(s, 0, bits.UintSize); e != nil {
+		err = e
 	} else {
-		ret = f
+		ret = uint(i) // no negative for hex.
 	}
 	return
 }
 
-// in golang, leading +/- are unary operators;
-// here, they are considered optional parts decimal numbers.
-// note: strconv's base 10 parser doesnt handle leading signs.
-// we therefore leave them out of our result, and just flag the negative ones.
-func unary(s string) (ret string, negate bool) {
-	switch s[0] {
-	case '-':
-		ret = s[1:]
-		negate = true
-	case '+':
-		ret = s[1:]
-	default:
-		ret = s
-	}
-	return
+// decimal and hex floats, with or without underscores.
+func fromFloat(s string) (ret float64, err error) {
+	return strconv.ParseFloat(s, 64)
 }
@@ -2,6 +2,7 @@ package charmed
 
 import (
 	"math"
+	"math/big"
 	"strings"
 	"testing"
 
@@ -73,9 +74,36 @@ func TestNum(t *testing.T) {
 		// bad leads
 		{"-0x5", 3, NaN},
 		{"+0x5", 3, NaN},
+		// separators
+		{"1_000", 0, 1000},
+		{"1_000.000_5", 0, 1000.0005},
+		{"1e1_0", 0, 1e10},
+		{"0_600", 0, 600}, // unlike go, leading zeros are decimal
+		{"0x_FF", 0, 0xff},
+		{"1__0", 3, NaN},
+		{"1_", -1, NaN},
+		{"1_.5", 3, NaN},
+		{"0x__FF", 4, NaN},
+		// octal and binary
+		{"0o755", 0, 0o755},
+		{"0O17", 0, 0o17},
+		{"0b1010", 0, 0b1010},
+		{"0B1_0", 0, 0b10},
+		{"0o8", 3, NaN},
+		{"0b2", 3, NaN},
+		{"0b", -1, NaN},
+		// hex floats
+		{"0x1.8p1", 0, 3},
+		{"0x1p-2", 0, 0.25},
+		{"0X1_0P0", 0, 16},
+		{"0x1.", -1, NaN},
+		{"0x1.8", -1, NaN},
+		{"0x1p", -1, NaN},
+		// inf is only allowed when enabled
+		{"+inf", 2, NaN},
+		// out of range for an int, but fine for a float:
+		{"170141183460469231731687303715884105727", 0, 1.7014118346046923e+38},
 	}
-	// out of range:
-	// {"170141183460469231731687303715884105727", 0, 1.7014118346046923e+38},
 	for i, test := range tests {
 		t.Logf("test%2d: '%s'", i, test.input)
 		if v, e := run(test.input); e == nil {
@@ -108,3 +136,109 @@ type endpointError struct {
 	error
 	pos int
 }
+
+// numbers which dont fit should error, or become big numbers.
+func TestNumModes(t *testing.T) {
+	parse := func(str string) (ret *NumParser) {
+		ret = new(NumParser)
+		if e := charm.ParseEof(str, ret.Decode()); e != nil {
+			t.Fatal(e)
+		}
+		return
+	}
+	tests := []struct {
+		input string
+		value any // the default mode
+		big   any // big numbers
+	}{
+		{"42", 42, 42},
+		{"-9223372036854775808", math.MinInt64, math.MinInt64},
+		{"0xffffffffffffffff", uint(math.MaxUint64), uint(math.MaxUint64)},
+		{"2.5", 2.5, 2.5},
+		{"99999999999999999999", nil, bigInt("99999999999999999999")},
+		{"-99999999999999999999", nil, bigInt("-99999999999999999999")},
+		{"0x1ffffffffffffffff", nil, bigInt("0x1ffffffffffffffff")},
+		{"1e400", nil, bigFloat("1e400")},
+	}
+	for i, test := range tests {
+		p := parse(test.input)
+		if v, e := p.GetNumber(); test.value == nil && e == nil {
+			t.Errorf("test %d %q expected an error, got %v", i, test.input, v)
+		} else if test.value != nil && (e != nil || v != test.value) {
+			t.Errorf("test %d %q expected %v, got %v(%T) %v", i, test.input, test.value, v, v, e)
+		}
+		if v, e := p.GetBigNumber(); e != nil {
+			t.Errorf("test %d %q big failed %v", i, test.input, e)
+		} else if !sameNumber(v, test.big) {
+			t.Errorf("test %d %q expected %v(%T), got %v(%T)", i, test.input, test.big, test.big, v, v)
+		}
+		if v, e := p.GetText(); e != nil || v.String() != test.input {
+			t.Errorf("test %d %q text failed %v %v", i, test.input, v, e)
+		}
+	}
+	// floats of any size
+	if v, e := parse("1e400").GetBigFloat(); e != nil || !sameNumber(v, bigFloat("1e400")) {
+		t.Error("expected a big float", v, e)
+	} else if v, e := parse("1e300").GetBigFloat(); e != nil || v != 1e300 {
+		t.Error("expected a float64", v, e)
+	}
+}
+
+func TestInf(t *testing.T) {
+	for _, str := range []string{"+inf", "-inf"} {
+		p := NumParser{AllowInf: true}
+		if e := charm.ParseEof(str, p.Decode()); e != nil {
+			t.Fatal(e)
+		} else if v, e := p.GetNumber(); e != nil {
+			t.Fatal(e)
+		} else if f := v.(float64); !math.IsInf(f, 0) || (f < 0) != (str[0] == '-') {
+			t.Fatal("unexpected value", v)
+		} else if n, e := p.GetText(); e != nil || n.String() != str {
+			t.Fatal("unexpected text", n, e)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	if i, e := Number("0x20").Int64(); e != nil || i != 32 {
+		t.Error("hex", i, e)
+	} else if i, e := Number("-0600").Int64(); e != nil || i != -600 {
+		t.Error("leading zeros", i, e)
+	} else if f, e := Number("1e-3").Float64(); e != nil || f != 0.001 {
+		t.Error("float", f, e)
+	} else if _, e := Number("0xffffffffffffffff").Int64(); e == nil {
+		t.Error("expected out of range")
+	} else if i, e := Number("0b1_010").Int64(); e != nil || i != 10 {
+		t.Error("binary", i, e)
+	} else if i, e := Number("1_000").Int64(); e != nil || i != 1000 {
+		t.Error("separators", i, e)
+	} else if f, e := Number("0x1.8p1").Float64(); e != nil || f != 3 {
+		t.Error("hex float", f, e)
+	} else if f, e := Number("-inf").Float64(); e != nil || !math.IsInf(f, -1) {
+		t.Error("inf", f, e)
+	}
+}
+
+func bigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 0)
+	return i
+}
+
+func bigFloat(s string) *big.Float {
+	f, _, _ := big.ParseFloat(s, 10, 64, big.ToNearestEven)
+	return f
+}
+
+func sameNumber(a, b any) (okay bool) {
+	switch a := a.(type) {
+	case *big.Int:
+		b, ok := b.(*big.Int)
+		okay = ok && a.Cmp(b) == 0
+	case *big.Float:
+		b, ok := b.(*big.Float)
+		okay = ok && a.Cmp(b) == 0
+	default:
+		okay = a == b
+	}
+	return
+}
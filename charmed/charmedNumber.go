@@ -0,0 +1,108 @@
+package charmed
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ionous/tell/charm"
+)
+
+// controls the values produced for numbers.
+type NumberMode int
+
+const (
+	// int, uint ( for hex ), or float64;
+	// numbers which don't fit are an error.
+	NumberDefault NumberMode = iota
+	// like NumberDefault, but numbers which don't fit
+	// become *big.Int or *big.Float.
+	NumberBig
+	// a Number holding the text exactly as written.
+	NumberText
+)
+
+// Number holds the text of a number exactly as it was written.
+// ( similar to encoding/json's Number )
+type Number string
+
+// returns the text of the number.
+func (n Number) String() string {
+	return string(n)
+}
+
+// returns the value the decoder would normally produce for this number:
+// int, uint ( for hex, octal, and binary ), or float64.
+// errors if the text isn't a valid number.
+func (n Number) Value() (ret any, err error) {
+	if p, e := n.parse(); e != nil {
+		err = e
+	} else {
+		ret, err = p.GetNumber()
+	}
+	return
+}
+
+// errors if the text isn't a valid number.
+// ( inf and nan are considered valid )
+func (n Number) Validate() (err error) {
+	_, err = n.parse()
+	return
+}
+
+func (n Number) parse() (ret *NumParser, err error) {
+	switch s := string(n); s {
+	case "inf", "nan":
+		// unsigned inf and nan are words, not numbers.
+		var p NumParser
+		p.runes.WriteString(s)
+		p.mode = modeFloat
+		ret = &p
+	default:
+		p := NumParser{AllowInf: true}
+		if e := charm.ParseEof(s, p.Decode()); e != nil {
+			err = fmt.Errorf("invalid number %q: %w", s, e)
+		} else if p.mode == modePending {
+			err = fmt.Errorf("invalid number %q", s)
+		} else {
+			ret = &p
+		}
+	}
+	return
+}
+
+// returns the number as an integer.
+func (n Number) Int64() (ret int64, err error) {
+	if s := string(n); !hasPrefix(s) {
+		ret, err = strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 10, 64)
+	} else if u, e := strconv.ParseUint(s, 0, 64); e != nil {
+		err = e
+	} else if ret = int64(u); ret < 0 {
+		err = &strconv.NumError{Func: "Int64", Num: s, Err: strconv.ErrRange}
+	}
+	return
+}
+
+// returns the number as a float.
+func (n Number) Float64() (ret float64, err error) {
+	if s := string(n); !hasPrefix(s) || strings.ContainsAny(s, "pP") {
+		// handles decimals, hex floats, inf and nan.
+		ret, err = strconv.ParseFloat(s, 64)
+	} else if u, e := strconv.ParseUint(s, 0, 64); e != nil {
+		err = e
+	} else {
+		ret = float64(u)
+	}
+	return
+}
+
+// hex, octal, and binary numbers start with a prefix
+func hasPrefix(s string) (okay bool) {
+	if len(s) > 1 && s[0] == '0' {
+		switch s[1] {
+		case 'x', 'X', 'o', 'O', 'b', 'B':
+			okay = true
+		}
+	}
+	return
+}
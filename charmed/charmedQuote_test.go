@@ -46,7 +46,7 @@ func TestQuotes(t *testing.T) {
 // scans until the matching quote marker is found
 func testRemainingString(match rune, onDone func(string)) (ret charm.State) {
 	var buf strings.Builder
-	return charm.Step(scanRemainingString(&buf, match, AllowEscapes),
+	return charm.Step(scanRemainingString(&buf, match, AllowEscapes, nil),
 		charm.OnExit("recite", func() {
 			onDone(buf.String())
 		}))
@@ -41,17 +41,17 @@ func DecodePipe(out *strings.Builder) charm.State {
 
 // read until a (new) double-quote (") marker is found.
 func DecodeDouble(out *strings.Builder) charm.State {
-	return scanRemainingString(out, runes.QuoteDouble, AllowHere|AllowEscapes|FoldLines)
+	return scanRemainingString(out, runes.QuoteDouble, AllowHere|AllowEscapes|FoldLines, nil)
 }
 
 // read until a (new) single-quote (') marker is found.
 func DecodeSingle(out *strings.Builder) charm.State {
-	return scanRemainingString(out, runes.QuoteSingle, AllowHere|FoldLines)
+	return scanRemainingString(out, runes.QuoteSingle, AllowHere|FoldLines, nil)
 }
 
 // read until a (new) back-tick (`) marker is found.
 func DecodeRaw(out *strings.Builder) charm.State {
-	return scanRemainingString(out, runes.QuoteRaw, AllowHere)
+	return scanRemainingString(out, runes.QuoteRaw, AllowHere, nil)
 }
 
 // these control how inline strings are processed
@@ -78,7 +78,8 @@ func (p *pendingSpace) writeSpace(out *strings.Builder) {
 
 // a leading quote has already been processed
 // returns unhandled after the closing quote, or error if finished incorrectly.
-func scanRemainingString(out *strings.Builder, match rune, opt QuoteOptions) charm.State {
+// if the string is a heredoc, and doc is non-nil, records the heredoc's header.
+func scanRemainingString(out *strings.Builder, match rune, opt QuoteOptions, doc *Heredoc) charm.State {
 	var padding pendingSpace
 	var lineStarted bool // the start of the string isnt the start of a line
 	return charm.Self("scanQuote", func(self charm.State, q rune) (ret charm.State) {
@@ -122,7 +123,7 @@ func scanRemainingString(out *strings.Builder, match rune, opt QuoteOptions) cha
 			// for a heredoc: we eat the matching quote, *and* the next rune, then parse the doc.
 			ret = charm.Statement("quoted", func(secondRune rune) (ret charm.State) {
 				if allowHere && secondRune == match {
-					ret = decodeHereAfter(out, match)
+					ret = decodeHereAfter(out, match, doc)
 				}
 				return
 			})
@@ -134,11 +135,13 @@ func scanRemainingString(out *strings.Builder, match rune, opt QuoteOptions) cha
 // three opening quotes have been found:
 // 1. read the custom closing tag ( if any )
 // 2. read here doc lines until the closing tag
-func decodeHereAfter(out *strings.Builder, quote rune) charm.State {
+// ( if doc is non-nil, it receives the details of the header )
+func decodeHereAfter(out *strings.Builder, quote rune, doc *Heredoc) charm.State {
 	// start with this as the closing tag
 	var endTag = []rune{quote, quote, quote}
+	var lang string
 	// the tag expands to fit whatever override the user specified (if anything)
-	tagReader := decoodeTag(&endTag)
+	tagReader := decoodeTag(&endTag, &lang)
 	// after determining the customTag
 	return charm.Step(tagReader, charm.Statement("capture", func(q rune) (ret charm.State) {
 		// can't call directly, or it wont see the (possibly new) slice from decode tag
@@ -146,6 +149,12 @@ func decodeHereAfter(out *strings.Builder, quote rune) charm.State {
 		if q != runes.Newline {
 			ret = charm.Error(charm.InvalidRune(q))
 		} else {
+			if doc != nil {
+				doc.Style, doc.Lang = quote, lang
+				if tag := string(endTag); tag != strings.Repeat(string(quote), 3) {
+					doc.Tag = tag
+				}
+			}
 			ret = decodeUntilCustom(out, quote, endTag)
 		}
 		return
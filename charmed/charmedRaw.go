@@ -0,0 +1,7 @@
+package charmed
+
+// RawValue holds the source text of a value, rather than its decoded contents.
+// ( similar to json.RawMessage. )
+// the text is a tell document of its own:
+// it can be decoded later, or written back out exactly as it was read.
+type RawValue []byte
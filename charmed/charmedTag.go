@@ -7,7 +7,8 @@ import (
 )
 
 // determine whether the first line of the heredoc has a custom closing tag
-func decoodeTag(tag *[]rune) charm.State {
+// and record the first word before any redirect as the heredoc's language.
+func decoodeTag(tag *[]rune, lang *string) charm.State {
 	type headerStage int
 	const (
 		waitingForRedirect headerStage = iota
@@ -26,6 +27,9 @@ func decoodeTag(tag *[]rune) charm.State {
 				*tag, buf = buf, nil
 				stage = haveTag
 			} else {
+				if len(*lang) == 0 {
+					*lang = string(buf)
+				}
 				buf = buf[:0] // reset
 			}
 
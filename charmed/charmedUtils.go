@@ -24,7 +24,7 @@ func UnhandledError(watch charm.State) charm.State {
 // returns an state which errors on all control codes other than newlines
 func FilterInvalidRunes() charm.State {
 	return charm.Self("filter control codes", func(next charm.State, q rune) charm.State {
-		if isInvalidRune(q) {
+		if IsInvalidRune(q) {
 			e := charm.InvalidRune(q)
 			next = charm.Error(e)
 		}
@@ -32,7 +32,9 @@ func FilterInvalidRunes() charm.State {
 	})
 }
 
-func isInvalidRune(q rune) (ret bool) {
+// true for control codes other than tabs and newlines.
+// ( tabs are left for the tokenizer to catch outside of strings and comments. )
+func IsInvalidRune(q rune) (ret bool) {
 	switch q {
 	case runes.HTab, runes.Space, runes.Newline, runes.Eof:
 		ret = false
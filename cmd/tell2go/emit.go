@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// generates go source for the types of a shape.
+type writer struct {
+	buf     bytes.Buffer
+	names   map[string]bool // type names already in use
+	pending []named         // structs waiting to be written
+}
+
+type named struct {
+	name  string
+	shape *shape
+}
+
+// return formatted go source describing the passed shape.
+// the shape of the whole document is called typeName.
+func writeTypes(pkg, typeName string, s *shape) (ret []byte, err error) {
+	w := writer{names: make(map[string]bool)}
+	fmt.Fprintf(&w.buf, "// Code generated by tell2go. DO NOT EDIT.\n\npackage %s\n", pkg)
+	if s.kind == kindMapping {
+		w.typeOf(s, typeName) // queues the struct
+	} else {
+		w.names[typeName] = true
+		fmt.Fprintf(&w.buf, "\ntype %s %s\n", typeName, w.typeOf(s, typeName))
+	}
+	for len(w.pending) > 0 {
+		next := w.pending[0]
+		w.pending = w.pending[1:]
+		w.writeStruct(next.name, next.shape)
+	}
+	if src, e := format.Source(w.buf.Bytes()); e != nil {
+		err = fmt.Errorf("generated invalid go: %w", e)
+	} else {
+		ret = src
+	}
+	return
+}
+
+func (w *writer) writeStruct(name string, s *shape) {
+	fmt.Fprintf(&w.buf, "\ntype %s struct {\n", name)
+	used := make(map[string]bool)
+	for _, f := range s.fields {
+		fieldName := unique(used, goName(f.key))
+		fieldType := w.typeOf(f.shape, fieldName)
+		fmt.Fprintf(&w.buf, "%s %s `tell:%s`\n", fieldName, fieldType, strconv.Quote(f.key))
+	}
+	w.buf.WriteString("}\n")
+}
+
+// the go type for a shape;
+// mappings get queued as new struct types named after the passed hint.
+func (w *writer) typeOf(s *shape, hint string) (ret string) {
+	switch s.kind {
+	case kindBool:
+		ret = "bool"
+	case kindInt:
+		ret = "int"
+	case kindUint:
+		ret = "uint"
+	case kindFloat:
+		ret = "float64"
+	case kindString:
+		ret = "string"
+	case kindMapping:
+		ret = unique(w.names, hint)
+		w.pending = append(w.pending, named{ret, s})
+	case kindSequence:
+		ret = "[]" + w.typeOf(s.elem, singular(hint))
+	default:
+		ret = "any"
+	}
+	return
+}
+
+// mark the passed name as used, adding a number to it if it was already in use.
+func unique(used map[string]bool, name string) (ret string) {
+	ret = name
+	for i := 2; used[ret]; i++ {
+		ret = name + strconv.Itoa(i)
+	}
+	used[ret] = true
+	return
+}
+
+// turn a key into an exported go name:
+// each word ( separated by spaces, colons, or other punctuation ) gets capitalized;
+// ex. "Related Projects:" becomes "RelatedProjects", and "Say:to:" becomes "SayTo".
+func goName(key string) (ret string) {
+	var out strings.Builder
+	for _, word := range strings.FieldsFunc(key, func(q rune) bool {
+		return !unicode.IsLetter(q) && !unicode.IsDigit(q)
+	}) {
+		rs := []rune(word)
+		rs[0] = unicode.ToUpper(rs[0])
+		out.WriteString(string(rs))
+	}
+	if ret = out.String(); len(ret) == 0 {
+		ret = "Field"
+	} else if !unicode.IsLetter([]rune(ret)[0]) {
+		ret = "X" + ret
+	}
+	return
+}
+
+// a name for the elements of a sequence.
+// ex. "Enemies" becomes "Enemy", "Points" becomes "Point".
+func singular(name string) (ret string) {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		ret = name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && len(name) > 1:
+		ret = name[:len(name)-1]
+	default:
+		ret = name + "Elem"
+	}
+	return
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io"
+
+	"github.com/ionous/tell"
+	"github.com/ionous/tell/collect/imap"
+)
+
+// the kinds of values seen in the samples.
+// ( ordered so that the scalar kinds come before the collections. )
+type kind int
+
+const (
+	kindNone kind = iota // no values yet, or only null values.
+	kindBool
+	kindInt
+	kindUint
+	kindFloat
+	kindString
+	kindMapping
+	kindSequence
+	kindAny // conflicting values
+)
+
+// everything observed about the values at some particular spot in the samples.
+type shape struct {
+	kind   kind
+	fields []field // for mappings: every key seen, in the order first seen.
+	elem   *shape  // for sequences: the merged shape of all elements.
+}
+
+type field struct {
+	key   string
+	shape *shape
+}
+
+// decode a sample document and merge it into this shape.
+func (s *shape) read(r io.Reader) (err error) {
+	var doc any
+	dec := tell.NewDecoder(r)
+	dec.SetMapper(imap.Make) // keeps the order of keys
+	if e := dec.Decode(&doc); e != nil {
+		err = e
+	} else {
+		s.observe(doc)
+	}
+	return
+}
+
+// merge the passed value into this shape.
+func (s *shape) observe(v any) {
+	switch v := v.(type) {
+	case nil:
+		// null values don't say anything about the type.
+	case bool:
+		s.merge(kindBool)
+	case int:
+		s.merge(kindInt)
+	case uint:
+		s.merge(kindUint) // hex values
+	case float64:
+		s.merge(kindFloat)
+	case string:
+		s.merge(kindString)
+	case imap.ItemMap:
+		if s.merge(kindMapping) {
+			for _, kv := range v {
+				s.field(kv.Key).observe(kv.Value)
+			}
+		}
+	case []any:
+		if s.merge(kindSequence) {
+			if s.elem == nil {
+				s.elem = new(shape)
+			}
+			for _, el := range v {
+				s.elem.observe(el)
+			}
+		}
+	default:
+		s.merge(kindAny)
+	}
+}
+
+// combine the passed kind with the current kind;
+// returns false if the result can't hold any details.
+func (s *shape) merge(k kind) bool {
+	switch prev := s.kind; {
+	case prev == kindNone || prev == k:
+		s.kind = k
+	case isNumber(prev) && isNumber(k):
+		// ints and uints widen to floats; ints and uints mixed together become ints.
+		s.kind = max(prev, k)
+		if s.kind == kindUint {
+			s.kind = kindInt
+		}
+	default:
+		s.kind = kindAny
+		s.fields, s.elem = nil, nil
+	}
+	return s.kind != kindAny
+}
+
+// return the shape of the named key, adding it if this is its first use.
+func (s *shape) field(key string) (ret *shape) {
+	for _, f := range s.fields {
+		if f.key == key {
+			ret = f.shape
+			break
+		}
+	}
+	if ret == nil {
+		ret = new(shape)
+		s.fields = append(s.fields, field{key, ret})
+	}
+	return
+}
+
+func isNumber(k kind) bool {
+	return k == kindInt || k == kindUint || k == kindFloat
+}
@@ -0,0 +1,89 @@
+// Tell2go writes Go struct definitions for one or more sample tell documents.
+//
+// Usage:
+//
+//	tell2go [-pkg name] [-type TypeName] [-o file] sample.tell...
+//
+// Each mapping becomes a struct, and each key of a mapping becomes a field.
+// The name of a field comes from the words of its key: "Related Projects:" becomes RelatedProjects.
+// Every field gets a `tell:"..."` struct tag containing its original key ( the same tag tellgen reads. )
+// ( The tag records the key for tools; the tell package itself doesn't read it. )
+// Nested mappings become their own named struct types; sequences become slices.
+//
+// Scalar types are inferred from their values:
+// booleans become bool, decimal integers int, hex values uint, other numbers float64, and strings string.
+// The samples are merged together: a field can appear in some samples and not others,
+// an int seen alongside a float becomes float64, and a field with conflicting values becomes any.
+// ( So do fields which only ever hold null values. )
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+func main() {
+	pkg := flag.String("pkg", "main", "package name of the generated file")
+	typeName := flag.String("type", "Document", "name of the type describing a whole document")
+	out := flag.String("o", "", "output file ( defaults to stdout )")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: tell2go [-pkg name] [-type TypeName] [-o file] sample.tell...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if e := run(*pkg, *typeName, *out, flag.Args()); e != nil {
+		log.Fatal(e)
+	}
+}
+
+func run(pkg, typeName, outFile string, files []string) (err error) {
+	var s shape
+	for _, name := range files {
+		if fp, e := os.Open(name); e != nil {
+			err = e
+			break
+		} else {
+			e := s.read(fp)
+			fp.Close()
+			if e != nil {
+				err = fmt.Errorf("%s: %w", name, e)
+				break
+			}
+		}
+	}
+	if err == nil {
+		if len(outFile) == 0 {
+			err = generate(os.Stdout, pkg, typeName, &s)
+		} else if fp, e := os.Create(outFile); e != nil {
+			err = e
+		} else {
+			w := bufio.NewWriter(fp)
+			err = generate(w, pkg, typeName, &s)
+			if e := w.Flush(); e != nil && err == nil {
+				err = e
+			}
+			if e := fp.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return
+}
+
+// write go source for the passed shape to the passed writer.
+func generate(w io.Writer, pkg, typeName string, s *shape) (err error) {
+	if src, e := writeTypes(pkg, typeName, s); e != nil {
+		err = e
+	} else {
+		_, err = w.Write(src)
+	}
+	return
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	const want = "// Code generated by tell2go. DO NOT EDIT.\n" +
+		"\n" +
+		"package sample\n" +
+		"\n" +
+		"type Project struct {\n" +
+		"\tName            string           `tell:\"Name:\"`\n" +
+		"\tVersion         float64          `tell:\"Version:\"`\n" +
+		"\tStars           uint             `tell:\"Stars:\"`\n" +
+		"\tPublic          any              `tell:\"Public:\"`\n" +
+		"\tRelatedProjects []RelatedProject `tell:\"Related Projects:\"`\n" +
+		"\tEnemies         []Enemy          `tell:\"Enemies:\"`\n" +
+		"\tOwner           Owner            `tell:\"Owner:\"`\n" +
+		"\tNotes           any              `tell:\"Notes:\"`\n" +
+		"\tSayTo           string           `tell:\"Say:to:\"`\n" +
+		"}\n" +
+		"\n" +
+		"type RelatedProject struct {\n" +
+		"\tName string `tell:\"Name:\"`\n" +
+		"\tUrl  string `tell:\"Url:\"`\n" +
+		"}\n" +
+		"\n" +
+		"type Enemy struct {\n" +
+		"\tType     string    `tell:\"Type:\"`\n" +
+		"\tHealth   int       `tell:\"Health:\"`\n" +
+		"\tPosition []float64 `tell:\"Position:\"`\n" +
+		"\tBoss     bool      `tell:\"Boss:\"`\n" +
+		"}\n" +
+		"\n" +
+		"type Owner struct {\n" +
+		"\tName  string `tell:\"Name:\"`\n" +
+		"\tSince int    `tell:\"Since:\"`\n" +
+		"}\n"
+	var s shape
+	for _, name := range []string{"testdata/first.tell", "testdata/second.tell"} {
+		if fp, e := os.Open(name); e != nil {
+			t.Fatal(e)
+		} else {
+			e := s.read(fp)
+			fp.Close()
+			if e != nil {
+				t.Fatal(name, e)
+			}
+		}
+	}
+	var buf strings.Builder
+	if e := generate(&buf, "sample", "Project", &s); e != nil {
+		t.Fatal(e)
+	} else if have := buf.String(); have != want {
+		t.Fatalf("have:\n%s\nwant:\n%s", have, want)
+	} else if _, e := parser.ParseFile(token.NewFileSet(), "", have, 0); e != nil {
+		t.Fatal(e)
+	}
+}
+
+// documents which aren't mappings get a named type of their own.
+func TestGenerateSequence(t *testing.T) {
+	const want = "// Code generated by tell2go. DO NOT EDIT.\n" +
+		"\n" +
+		"package main\n" +
+		"\n" +
+		"type Points []Point\n" +
+		"\n" +
+		"type Point struct {\n" +
+		"\tX int `tell:\"X:\"`\n" +
+		"\tY int `tell:\"Y:\"`\n" +
+		"}\n"
+	var s shape
+	if e := s.read(strings.NewReader("- X: 1\n  Y: 2\n- X: 3\n")); e != nil {
+		t.Fatal(e)
+	}
+	var buf strings.Builder
+	if e := generate(&buf, "main", "Points", &s); e != nil {
+		t.Fatal(e)
+	} else if have := buf.String(); have != want {
+		t.Fatalf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for key, want := range map[string]string{
+		"Related Projects:": "RelatedProjects",
+		"Say:to:":           "SayTo",
+		"name:":             "Name",
+		"x-ray_vision:":     "XRayVision",
+		"3d:":               "X3d",
+	} {
+		if have := goName(key); have != want {
+			t.Errorf("%q: have %q want %q", key, have, want)
+		}
+	}
+}
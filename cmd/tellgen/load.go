@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// a type checked go package, with the doc comments of its declarations.
+type goPackage struct {
+	pkg  *types.Package
+	docs map[token.Pos]*ast.CommentGroup // keyed by the position of a field or type name
+}
+
+// parse and type check the ( non-test ) go files in the passed directory.
+// imported packages are type checked from their source.
+func loadPackage(dir string) (ret goPackage, err error) {
+	fset := token.NewFileSet()
+	if files, e := parseDir(fset, dir); e != nil {
+		err = e
+	} else if len(files) == 0 {
+		err = fmt.Errorf("no go files found in %q", dir)
+	} else {
+		cfg := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+		if pkg, e := cfg.Check(files[0].Name.Name, fset, files, nil); e != nil {
+			err = e
+		} else {
+			ret = goPackage{pkg: pkg, docs: collectDocs(files)}
+		}
+	}
+	return
+}
+
+func parseDir(fset *token.FileSet, dir string) (ret []*ast.File, err error) {
+	if entries, e := os.ReadDir(dir); e != nil {
+		err = e
+	} else {
+		for _, el := range entries {
+			name := el.Name()
+			if el.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+				continue
+			} else if ok, e := build.Default.MatchFile(dir, name); e != nil {
+				err = e
+				break
+			} else if ok {
+				if f, e := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments); e != nil {
+					err = e
+					break
+				} else {
+					ret = append(ret, f)
+				}
+			}
+		}
+	}
+	return
+}
+
+// record the doc comments of types and struct fields
+// ( falling back to line comments for fields without a doc comment. )
+func collectDocs(files []*ast.File) map[token.Pos]*ast.CommentGroup {
+	docs := make(map[token.Pos]*ast.CommentGroup)
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.GenDecl:
+				for _, spec := range n.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						doc := ts.Doc
+						if doc == nil && len(n.Specs) == 1 {
+							doc = n.Doc
+						}
+						if doc != nil {
+							docs[ts.Name.Pos()] = doc
+						}
+					}
+				}
+			case *ast.StructType:
+				for _, field := range n.Fields.List {
+					doc := field.Doc
+					if doc == nil {
+						doc = field.Comment
+					}
+					if doc != nil {
+						if len(field.Names) == 0 {
+							docs[embeddedPos(field.Type)] = doc
+						}
+						for _, name := range field.Names {
+							docs[name.Pos()] = doc
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	return docs
+}
+
+// the type checker records the position of an embedded field
+// as the position of its type name.
+func embeddedPos(expr ast.Expr) (ret token.Pos) {
+	switch x := expr.(type) {
+	case *ast.StarExpr:
+		ret = embeddedPos(x.X)
+	case *ast.SelectorExpr:
+		ret = x.Sel.Pos()
+	case *ast.IndexExpr:
+		ret = embeddedPos(x.X)
+	default:
+		ret = expr.Pos()
+	}
+	return
+}
+
+// the comment lines for a doc comment
+func (p *goPackage) docLines(at token.Pos) (ret []string) {
+	if doc := p.docs[at]; doc != nil {
+		text := strings.TrimSuffix(doc.Text(), "\n")
+		for _, el := range strings.Split(text, "\n") {
+			if len(el) == 0 {
+				ret = append(ret, "#")
+			} else {
+				ret = append(ret, "# "+el)
+			}
+		}
+	}
+	return
+}
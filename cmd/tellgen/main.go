@@ -0,0 +1,75 @@
+// Tellgen writes a sample tell document for a Go struct type:
+// every field of the struct, its default value, and its doc comment.
+//
+// Usage:
+//
+//	tellgen [-dir directory] [-o file] TypeName
+//
+// The type is read from the Go package in the passed directory ( by default, the current directory. )
+//
+// Each exported field becomes a key: the name of the field followed by a colon,
+// or the name given by a `tell:"..."` struct tag. ( A tag of "-" skips the field. )
+// The value of each key is the zero value of its type,
+// or the tell value written in a `default:"..."` struct tag.
+// ( These tags are conventions shared with tell2go, which writes the same `tell` tag;
+// the tell package itself doesn't read them. )
+// Nested structs become mappings, and the fields of embedded structs are merged into their parent.
+// A slice of structs becomes a sequence containing a single sample element.
+// The doc comment of each field becomes a header comment for its key.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the go package containing the type")
+	out := flag.String("o", "", "output file ( defaults to stdout )")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: tellgen [-dir directory] [-o file] TypeName")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if e := run(*dir, flag.Arg(0), *out); e != nil {
+		log.Fatal(e)
+	}
+}
+
+func run(dir, typeName, outFile string) (err error) {
+	if len(outFile) == 0 {
+		err = generate(os.Stdout, dir, typeName)
+	} else if fp, e := os.Create(outFile); e != nil {
+		err = e
+	} else {
+		w := bufio.NewWriter(fp)
+		err = generate(w, dir, typeName)
+		if e := w.Flush(); e != nil && err == nil {
+			err = e
+		}
+		if e := fp.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// write a sample document for the named type to the passed writer.
+func generate(w io.Writer, dir, typeName string) (err error) {
+	if pkg, e := loadPackage(dir); e != nil {
+		err = e
+	} else if m, doc, e := pkg.sample(typeName); e != nil {
+		err = e
+	} else {
+		err = writeSample(w, m, doc)
+	}
+	return
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/ionous/tell"
+	"github.com/ionous/tell/encode"
+	"github.com/ionous/tell/runes"
+)
+
+// an ordered mapping which supplies a comment for each key
+type sample struct {
+	keys []string
+	vals []any
+	cmts map[string]encode.Comment
+}
+
+func (m *sample) add(key string, val any, header []string) {
+	m.keys = append(m.keys, key)
+	m.vals = append(m.vals, val)
+	if len(header) > 0 {
+		if m.cmts == nil {
+			m.cmts = make(map[string]encode.Comment)
+		}
+		m.cmts[key] = encode.Comment{Header: header}
+	}
+}
+
+func (m *sample) TellMapping() encode.Iterator {
+	return &sampleIt{m: m}
+}
+
+func (m *sample) TellKeyComments() map[string]encode.Comment {
+	return m.cmts
+}
+
+type sampleIt struct {
+	m    *sample
+	next int
+}
+
+func (it *sampleIt) Next() (okay bool) {
+	if okay = it.next < len(it.m.keys); okay {
+		it.next++
+	}
+	return
+}
+
+func (it *sampleIt) GetKey() string {
+	return it.m.keys[it.next-1]
+}
+
+func (it *sampleIt) GetValue() any {
+	return it.m.vals[it.next-1]
+}
+
+// write the sample; separating the doc comment of the type from the first field.
+func writeSample(w io.Writer, m *sample, doc []string) error {
+	var block string
+	if len(doc) > 0 {
+		block = strings.Join(append(doc, string(runes.BlankLine)), "\n")
+	}
+	enc := encode.MakeEncoder(w)
+	return enc.EncodeWithComments(m, block)
+}
+
+var errNotStruct = errors.New("expected a struct type")
+
+// generate a sample for the named type
+// returns the type's doc comment as well.
+func (p *goPackage) sample(typeName string) (ret *sample, doc []string, err error) {
+	if obj, ok := p.pkg.Scope().Lookup(typeName).(*types.TypeName); !ok {
+		err = fmt.Errorf("type %s not found in package %s", typeName, p.pkg.Name())
+	} else if str, ok := obj.Type().Underlying().(*types.Struct); !ok {
+		err = fmt.Errorf("%w; %s is a %s", errNotStruct, typeName, obj.Type().Underlying())
+	} else {
+		g := generator{goPackage: p, visiting: make(map[types.Type]bool)}
+		g.visiting[obj.Type()] = true
+		ret = new(sample)
+		if e := g.fields(ret, str); e != nil {
+			err = e
+		} else {
+			doc = p.docLines(obj.Pos())
+		}
+	}
+	return
+}
+
+type generator struct {
+	*goPackage
+	visiting map[types.Type]bool // named types being generated; to avoid cycles
+}
+
+// add the fields of the passed struct to the sample
+func (g *generator) fields(out *sample, str *types.Struct) (err error) {
+	for i, cnt := 0, str.NumFields(); i < cnt && err == nil; i++ {
+		field := str.Field(i)
+		tag := reflect.StructTag(str.Tag(i))
+		name, _, _ := strings.Cut(tag.Get("tell"), ",")
+		if name == "-" || !field.Exported() {
+			continue
+		} else if embedded, ok := field.Type().Underlying().(*types.Struct); ok && field.Embedded() && len(name) == 0 {
+			err = g.fields(out, embedded)
+		} else if val, e := g.fieldValue(field, tag); e != nil {
+			err = fmt.Errorf("%s.%s %w", field.Pkg().Name(), field.Name(), e)
+		} else {
+			if len(name) == 0 {
+				name = field.Name() + ":"
+			} else if name[len(name)-1] != runes.Colon {
+				name += ":"
+			}
+			out.add(name, val, g.docLines(field.Pos()))
+		}
+	}
+	return
+}
+
+// the default value of a field
+func (g *generator) fieldValue(field *types.Var, tag reflect.StructTag) (ret any, err error) {
+	if str, ok := tag.Lookup("default"); !ok {
+		ret, err = g.value(field.Type())
+	} else if e := tell.Unmarshal([]byte(str), &ret); e != nil {
+		err = fmt.Errorf("invalid default %q: %w", str, e)
+	}
+	return
+}
+
+// the zero value of a type; structs generate samples.
+func (g *generator) value(t types.Type) (ret any, err error) {
+	if g.visiting[t] {
+		return // a recursive type; leave it empty.
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch info := u.Info(); {
+		case info&types.IsBoolean != 0:
+			ret = false
+		case info&types.IsInteger != 0:
+			ret = 0
+		case info&types.IsFloat != 0:
+			ret = tell.Number("0.0") // so that it reads as a float
+		case info&types.IsString != 0:
+			ret = ""
+		}
+	case *types.Pointer:
+		ret, err = g.value(u.Elem())
+	case *types.Struct:
+		if _, named := t.(*types.Named); named {
+			g.visiting[t] = true
+			defer delete(g.visiting, t)
+		}
+		m := new(sample)
+		if e := g.fields(m, u); e != nil {
+			err = e
+		} else {
+			ret = m
+		}
+	case *types.Slice:
+		ret, err = g.sequence(u.Elem())
+	case *types.Array:
+		ret, err = g.sequence(u.Elem())
+	}
+	// others ( maps, interfaces, channels, etc. ) are left empty
+	return
+}
+
+// sequences of structs contain one sample element;
+// all others are empty.
+func (g *generator) sequence(elem types.Type) (ret []any, err error) {
+	ret = []any{}
+	if isStruct(elem) {
+		if el, e := g.value(elem); e != nil {
+			err = e
+		} else if el != nil {
+			ret = append(ret, el)
+		}
+	}
+	return
+}
+
+func isStruct(t types.Type) (okay bool) {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	_, okay = t.Underlying().(*types.Struct)
+	return
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell"
+)
+
+func TestGenerate(t *testing.T) {
+	const want = "# Config describes a game server.\n" +
+		"# ( it's used to test tellgen )\n" +
+		"\n" +
+		"# the name shown to players.\n" +
+		"Name: \"\"\n" +
+		"Port: 8080\n" +
+		"# enables extra logging.\n" +
+		"Debug: false\n" +
+		"# how long to wait for players.\n" +
+		"Timeout: 0\n" +
+		"Scale: 0.0\n" +
+		"Admins: []\n" +
+		"# where players start.\n" +
+		"Spawn Points:\n" +
+		"  - X: 0\n" +
+		"    Y: 0\n" +
+		"Limits:\n" +
+		"  # the most players at once.\n" +
+		"  MaxPlayers: 16\n" +
+		"  # a recursive type; stops after one level.\n" +
+		"  Next:\n" +
+		"Tags:\n" +
+		"Extra:\n" +
+		"Version: \"1.0\"\n"
+	var buf strings.Builder
+	if e := generate(&buf, "testdata/config", "Config"); e != nil {
+		t.Fatal(e)
+	} else if have := buf.String(); have != want {
+		t.Fatalf("have:\n%s\nwant:\n%s", have, want)
+	}
+	// the sample should be a valid document
+	var res map[string]any
+	if e := tell.Unmarshal([]byte(want), &res); e != nil {
+		t.Fatal(e)
+	} else if res["Port:"] != 8080 || res["Scale:"] != 0.0 {
+		t.Fatal("unexpected values", res)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	var buf strings.Builder
+	if e := generate(&buf, "testdata/config", "Missing"); e == nil {
+		t.Fatal("expected an error for a missing type")
+	} else if e := generate(&buf, "testdata/missing", "Config"); e == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+	// types without doc comments are fine.
+	if e := generate(&buf, "testdata/config", "Point"); e != nil {
+		t.Fatal(e)
+	}
+	// a named slice type isnt a struct
+	if e := generate(&buf, "testdata/other", "Names"); !errors.Is(e, errNotStruct) {
+		t.Fatal("expected a struct error", e)
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import "time"
+
+// Config describes a game server.
+// ( it's used to test tellgen )
+type Config struct {
+	// the name shown to players.
+	Name  string
+	Port  int  `default:"8080"`
+	Debug bool // enables extra logging.
+	// how long to wait for players.
+	Timeout time.Duration
+	Scale   float64
+	Admins  []string
+	// where players start.
+	Spawns  []Point `tell:"Spawn Points:"`
+	Limits  *Limits
+	Tags    map[string]string
+	Extra   any
+	Skipped int `tell:"-"`
+	secret  string
+	Base
+}
+
+type Point struct {
+	X, Y int
+}
+
+type Limits struct {
+	// the most players at once.
+	MaxPlayers int `default:"16"`
+	// a recursive type; stops after one level.
+	Next *Limits
+}
+
+type Base struct {
+	Version string `default:"\"1.0\""`
+}
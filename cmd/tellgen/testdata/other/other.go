@@ -0,0 +1,3 @@
+package other
+
+type Names []string
@@ -0,0 +1,67 @@
+package collect
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Path identifies a value within a document
+// using the keys and indices leading to it from the root of the document.
+// For example: "Enemies:/3/Type:" is the value of the key "Type:"
+// in the fourth element of the sequence stored at "Enemies:".
+// The root of the document is the empty path.
+//
+// Sequence indices are always zero-based, and mapping keys include their trailing colon.
+// ( Tell keys can't contain slashes, so the separator is never ambiguous. )
+type Path string
+
+// build a path from a series of keys ( strings ) and indices ( ints. )
+// panics if passed some other type.
+func MakePath(parts ...any) (ret Path) {
+	for _, el := range parts {
+		switch el := el.(type) {
+		case string:
+			ret = ret.Key(el)
+		case int:
+			ret = ret.Index(el)
+		default:
+			panic("paths can only contain strings and ints")
+		}
+	}
+	return
+}
+
+// the path of a value in the mapping at this path.
+func (p Path) Key(key string) Path {
+	return p.join(key)
+}
+
+// the path of an element in the sequence at this path.
+func (p Path) Index(idx int) Path {
+	return p.join(strconv.Itoa(idx))
+}
+
+// the keys ( as strings ) and indices ( as ints ) of the path.
+func (p Path) Parts() (ret []any) {
+	if len(p) > 0 {
+		for _, el := range strings.Split(string(p), pathSeparator) {
+			if idx, e := strconv.Atoi(el); e == nil {
+				ret = append(ret, idx)
+			} else {
+				ret = append(ret, el)
+			}
+		}
+	}
+	return
+}
+
+func (p Path) join(part string) (ret Path) {
+	if len(p) == 0 {
+		ret = Path(part)
+	} else {
+		ret = p + pathSeparator + Path(part)
+	}
+	return
+}
+
+const pathSeparator = "/"
@@ -0,0 +1,28 @@
+package collect
+
+import "github.com/ionous/tell/token"
+
+// where in a document a new collection begins.
+type Site struct {
+	Path Path      // the path of the collection; the root of the document is the empty path.
+	Key  string    // the first key of a mapping; empty for sequences.
+	At   token.Pos // the position of its first key, dash, or opening bracket.
+}
+
+// a function which returns a new writer for the mapping at the passed site
+// reserve indicates whether to keep space for a comment key
+type MapSiteFactory func(site Site, reserve bool) MapWriter
+
+// a function which returns a new writer for the sequence at the passed site
+// reserve indicates whether to keep space for comments
+type SequenceSiteFactory func(site Site, reserve bool) SequenceWriter
+
+// a site factory which ignores the site
+func (f MapFactory) AtAnySite(_ Site, reserve bool) MapWriter {
+	return f(reserve)
+}
+
+// a site factory which ignores the site
+func (f SequenceFactory) AtAnySite(_ Site, reserve bool) SequenceWriter {
+	return f(reserve)
+}
@@ -2,9 +2,15 @@ package decode_test
 
 import (
 	"errors"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/ionous/tell/collect/imap"
+	"github.com/ionous/tell/collect/stdmap"
+	"github.com/ionous/tell/collect/stdseq"
+	"github.com/ionous/tell/decode"
 )
 
 // -------------------------------------------------------------
@@ -43,3 +49,56 @@ func TestTabCatching(t *testing.T) {
 		errors.New("tabs are invalid"),
 		badTab)
 }
+
+// an empty array is the value of its key:
+// the next key shouldn't also see a missing value.
+// ( this used to generate an implicit nil at a blank key, visible in the positions. )
+func TestEmptyArrayValue(t *testing.T) {
+	for _, doc := range []struct {
+		src   string
+		paths []string
+	}{
+		{"a:\n  b: []\n  c: 1\n", []string{"", "a:", "a:/b:", "a:/c:"}},
+		{"a: []\nb: 1\n", []string{"", "a:", "b:"}},
+		{"- []\n- 2\n", []string{"", "0", "1"}},
+	} {
+		var dec decode.Decoder
+		dec.SetMapper(stdmap.Make)
+		dec.SetSequencer(stdseq.Make)
+		ps := make(decode.Positions)
+		dec.UsePositions(ps)
+		if _, e := dec.Decode(strings.NewReader(doc.src)); e != nil {
+			t.Fatal(e)
+		}
+		var paths []string
+		for p := range ps {
+			paths = append(paths, string(p))
+		}
+		sort.Strings(paths)
+		if !reflect.DeepEqual(paths, doc.paths) {
+			t.Errorf("%q have paths %q", doc.src, paths)
+		}
+	}
+}
+
+// decoding from bytes should report errors at the same position as decoding from a stream.
+func TestBytesErrorPos(t *testing.T) {
+	for i, doc := range []string{
+		"99999999999999999999",
+		"- 5\n- -99999999999999999999\n",
+		"Key: 0x1ffffffffffffffff\n",
+		"- \"unterminated\n",
+	} {
+		var dec decode.Decoder
+		dec.SetMapper(stdmap.Make)
+		dec.SetSequencer(stdseq.Make)
+		var a, b decode.ErrorPos
+		if _, e := dec.Decode(strings.NewReader(doc)); !errors.As(e, &a) {
+			t.Fatalf("test %d expected a positioned error, have %v", i, e)
+		} else if _, e := dec.DecodeBytes([]byte(doc)); !errors.As(e, &b) {
+			t.Fatalf("test %d expected a positioned error from bytes, have %v", i, e)
+		} else if a.Error() != b.Error() {
+			t.Errorf("test %d mismatched errors\nstream: %v\nbytes:  %v", i, a, b)
+		}
+	}
+}
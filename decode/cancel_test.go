@@ -0,0 +1,47 @@
+package decode_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell/collect/imap"
+	"github.com/ionous/tell/collect/stdseq"
+	"github.com/ionous/tell/decode"
+)
+
+// verify that canceling a context stops decoding,
+// and that the error includes the position of the decoder.
+func TestCancel(t *testing.T) {
+	str := strings.Repeat("- \"some value\"\n", 1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	// cancel part way through the document
+	src := &cancelReader{Reader: strings.NewReader(str), cancel: cancel, after: 5000}
+	var dec decode.Decoder
+	dec.SetMapper(imap.Make)
+	dec.SetSequencer(stdseq.Make)
+	if _, e := dec.DecodeContext(ctx, src); !errors.Is(e, context.Canceled) {
+		t.Fatal("expected cancellation, got", e)
+	} else if ep, ok := e.(decode.ErrorPos); !ok {
+		t.Fatal("expected a positioned error, got", e)
+	} else if y, _ := ep.Pos(); y == 0 {
+		t.Fatal("expected the decoder to have made some progress", e)
+	} else {
+		t.Log("ok:", e)
+	}
+}
+
+// cancels its context after reading some number of runes
+type cancelReader struct {
+	*strings.Reader
+	cancel func()
+	after  int
+}
+
+func (c *cancelReader) ReadRune() (rune, int, error) {
+	if c.after--; c.after == 0 {
+		c.cancel()
+	}
+	return c.Reader.ReadRune()
+}
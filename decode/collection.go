@@ -20,8 +20,9 @@ func newMapping(key string, values collect.MapWriter) *pendingMap {
 }
 
 type pendingMap struct {
-	key  string
-	maps collect.MapWriter
+	key    string
+	maps   collect.MapWriter
+	narrow bool // try to narrow the type of the finished map
 	note.Book
 }
 
@@ -32,7 +33,10 @@ func (p *pendingMap) finalize() (ret any) {
 	if str, ok := p.Resolve(); ok {
 		p.maps.MapValue("", str)
 	}
-	return p.maps.GetMap()
+	if ret = p.maps.GetMap(); p.narrow {
+		ret = narrowMapping(ret)
+	}
+	return
 }
 
 func (p *pendingMap) setKey(key string) (err error) {
@@ -61,15 +65,17 @@ func newSequence(values collect.SequenceWriter, reserve bool) *pendingSeq {
 	if reserve {
 		index++
 	}
-	return &pendingSeq{dashed: true, index: index, values: values}
+	return &pendingSeq{dashed: true, index: index, first: index, values: values}
 }
 
 type pendingSeq struct {
 	dashed   bool
 	blockNil bool /// fix: subcase this for arrays?
 	values   collect.SequenceWriter
+	narrow   bool // try to narrow the type of the finished sequence
 	note.Book
 	index int
+	first int // the index of the first element ( one, when reserving space for comments )
 }
 
 func (p *pendingSeq) finalize() (ret any) {
@@ -81,7 +87,10 @@ func (p *pendingSeq) finalize() (ret any) {
 	if str, ok := p.Resolve(); ok {
 		p.values = p.values.IndexValue(0, str)
 	}
-	return p.values.GetSequence()
+	if ret = p.values.GetSequence(); p.narrow {
+		ret = narrowSequence(ret)
+	}
+	return
 }
 
 func (p *pendingSeq) setKey(key string) (err error) {
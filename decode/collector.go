@@ -7,19 +7,27 @@ import (
 
 // factory for collections, arrays, and comments
 type collector struct {
-	maps           collect.MapFactory
-	seqs           collect.SequenceFactory
+	maps           collect.MapSiteFactory
+	seqs           collect.SequenceSiteFactory
+	sited          bool // the factories want the path of each collection
 	keepComments   bool
 	commentContext note.Context
+	sidecar        bool // comments are kept outside of the collections
+	narrow         bool // narrow homogeneous collections; see Decoder.UseNarrowTypes
 }
 
-func (f *collector) newCollection(key string) pendingValue {
+// should collections reserve space for comments?
+func (f *collector) reserve() bool {
+	return f.keepComments && !f.sidecar
+}
+
+func (f *collector) newCollection(site collect.Site) pendingValue {
 	var p pendingValue
 	switch {
-	case len(key) == 0:
-		p = f.newSequence()
+	case len(site.Key) == 0:
+		p = f.newSequence(site)
 	default:
-		p = f.newMapping(key)
+		p = f.newMapping(site)
 	}
 	if f.keepComments {
 		p.BeginCollection(&f.commentContext)
@@ -27,16 +35,26 @@ func (f *collector) newCollection(key string) pendingValue {
 	return p
 }
 
-func (f *collector) newSequence() *pendingSeq {
-	return newSequence(f.seqs(f.keepComments), f.keepComments)
+func (f *collector) newSequence(site collect.Site) *pendingSeq {
+	seq := newSequence(f.seqs(site, f.reserve()), f.reserve())
+	seq.narrow = f.narrowing()
+	return seq
+}
+
+func (f *collector) newMapping(site collect.Site) *pendingMap {
+	m := newMapping(site.Key, f.maps(site, f.reserve()))
+	m.narrow = f.narrowing()
+	return m
 }
 
-func (f *collector) newMapping(key string) *pendingMap {
-	return newMapping(key, f.maps(f.keepComments))
+// collections with space for comments don't get narrowed
+// ( the comments would stop them from being homogeneous )
+func (f *collector) narrowing() bool {
+	return f.narrow && !f.reserve()
 }
 
-func (f *collector) newArray() pendingValue {
-	seq := f.newSequence()
+func (f *collector) newArray(site collect.Site) pendingValue {
+	seq := f.newSequence(site)
 	seq.blockNil = true
 	if f.keepComments {
 		seq.BeginCollection(&f.commentContext)
@@ -0,0 +1,65 @@
+package decode_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ionous/tell/collect/stdmap"
+	"github.com/ionous/tell/collect/stdseq"
+	"github.com/ionous/tell/decode"
+)
+
+// one decoder should be able to read many documents, one after the other or all at once.
+func TestConcurrentDecode(t *testing.T) {
+	var dec decode.Decoder
+	dec.SetMapper(stdmap.Make)
+	dec.SetSequencer(stdseq.Make)
+	dec.UseFloats = true
+	docOf := func(i int) string {
+		return fmt.Sprintf("Name: \"doc %d\"\nValues:\n  - %d\n  - [%d, 5]\n", i, i, i)
+	}
+	want := func(i int) any {
+		return map[string]any{
+			"Name:":   fmt.Sprintf("doc %d", i),
+			"Values:": []any{float64(i), []any{float64(i), 5.0}},
+		}
+	}
+	// sequentially: the second decode shouldn't see anything of the first
+	for i := 0; i < 2; i++ {
+		if have, e := dec.Decode(strings.NewReader(docOf(i))); e != nil {
+			t.Fatal(e)
+		} else if !reflect.DeepEqual(have, want(i)) {
+			t.Fatalf("decode %d mismatched; have %#v", i, have)
+		}
+	}
+	// concurrently:
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var have any
+			var err error
+			if i%2 == 0 {
+				have, err = dec.Decode(strings.NewReader(docOf(i)))
+			} else {
+				have, err = dec.DecodeBytes([]byte(docOf(i)))
+			}
+			if err == nil && !reflect.DeepEqual(have, want(i)) {
+				err = fmt.Errorf("decode %d mismatched; have %#v", i, have)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		if e != nil {
+			t.Error(e)
+		}
+	}
+}
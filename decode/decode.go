@@ -1,6 +1,7 @@
 package decode
 
 import (
+	"context"
 	"fmt"
 	"io"
 
@@ -14,79 +15,254 @@ import (
 
 // configure the production of sequences
 func (d *Decoder) SetSequencer(seq collect.SequenceFactory) {
-	d.collector.seqs = seq
+	d.seqs = seq.AtAnySite
 }
 
 // configure the production of mappings
 func (d *Decoder) SetMapper(maps collect.MapFactory) {
-	d.collector.maps = maps
+	d.maps = maps.AtAnySite
+}
+
+// configure the production of sequences based on where they appear in a document.
+func (d *Decoder) SetSequencerAt(seq collect.SequenceSiteFactory) {
+	d.seqs = seq
+	d.sited = true
+}
+
+// configure the production of mappings based on where they appear in a document.
+// ( ex. to use one kind of map for the top level of a document, and another for its leaves. )
+func (d *Decoder) SetMapperAt(maps collect.MapSiteFactory) {
+	d.maps = maps
+	d.sited = true
 }
 
 // pass a valid target for document level comments
 // a nil disables comment collection
 // ( comments are disabled by default )
 func (d *Decoder) UseNotes(b *note.Book) {
-	d.docBlock = b
-	d.collector.keepComments = b != nil
+	d.notes = b
+	d.sidecar = nil
+}
+
+// pass a valid table to keep comments separate from the decoded values.
+// each comment block is recorded using the path of its collection;
+// sequences stay zero indexed, and mappings don't get a blank key.
+// this replaces any book passed to UseNotes:
+// the comments of a document scalar are stored in the table at the root path.
+// a nil disables comment collection.
+func (d *Decoder) UseSidecar(s note.Sidecar) {
+	d.notes = nil
+	d.sidecar = s
 }
 
 // read a tell document from the passed stream
 func (d *Decoder) Decode(src io.RuneReader) (ret any, err error) {
-	if d.docBlock == nil {
-		d.docBlock = note.Nothing{}
+	p := d.newParser()
+	return p.decode(charm.MakeParser(p.record(src)))
+}
+
+// read a tell document from the passed stream,
+// stopping early if the context is canceled or times out.
+// the context's error is returned wrapped with the position of the decoder.
+func (d *Decoder) DecodeContext(ctx context.Context, src io.RuneReader) (ret any, err error) {
+	p := d.newParser()
+	return p.decode(charm.MakeContextParser(ctx, p.record(src)))
+}
+
+// read a tell document from the passed slice.
+// this is faster than Decode, but otherwise produces the same results.
+func (d *Decoder) DecodeBytes(src []byte) (ret any, err error) {
+	p := d.newParser()
+	s := p.decodeDoc().Scanner(src)
+	p.out.cursor = s.Pos
+	if d.RawValues != nil {
+		p.out.raw = &rawSource{match: d.RawValues, text: src, read: s.Offset}
 	}
-	var x, y int
-	run := charm.Parallel("parallel",
-		charmed.FilterInvalidRunes(),
-		d.decodeDoc(), // tbd: wrap with charmed.UnhandledError()? why/why not.
-		charmed.DecodePos(&y, &x),
-	)
-	p := charm.MakeParser(src)
-	if e := p.ParseEof(run); e != nil {
-		err = ErrorAt(y, x, e)
+	if e := s.Scan(); e != nil {
+		at := s.Pos()
+		err = errorAt(at.Y, at.X, e)
 	} else {
-		ret, err = d.out.finalizeAll()
+		ret, err = p.finalizeAll()
 	}
 	return
 }
 
 // the decoder is *not* ready to use by default
 // the mapper, sequencer, and notes need to be set.
+// once configured, a decoder can read any number of documents,
+// including from several goroutines at once;
+// so long as it isn't reconfigured while decoding, and has no book, sidecar, or position table:
+// those receive the results of each decode.
 type Decoder struct {
+	maps      collect.MapSiteFactory
+	seqs      collect.SequenceSiteFactory
+	sited     bool // a factory wants the paths of its collections
+	notes     *note.Book
+	sidecar   note.Sidecar
+	positions Positions
+	hooks     []Hook
+	// configure the tokenizer for the next decode
+	UseFloats  bool
+	NumberMode charmed.NumberMode
+	UseInfNaN  bool
+	Scalars    []token.ScalarFactory
+	// produce charmed.Heredoc values for heredocs, rather than strings.
+	UseHeredocs bool
+	// record blank lines in comment blocks ( as runes.BlankLine )
+	// only has an effect when comments are being kept.
+	UseBlankLines bool
+	// values at paths which pass this test are decoded as charmed.RawValue:
+	// their source text, rather than their contents.
+	// ( the values of keys, and the elements of sequences;
+	// the elements of arrays are never raw.
+	// the text includes the value's comments. )
+	RawValues func(collect.Path) bool
+	// produce []string, []int, []float64, or []bool for sequences
+	// whose elements all have that type ( and similarly map[string]string, etc. )
+	// rather than []any and map[string]any.
+	// only has an effect for the standard collections, and when comments aren't kept in them.
+	UseNarrowTypes bool
+}
+
+// the state of a single decode.
+type parser struct {
+	*Decoder  // the configuration; read only.
 	out       output
 	collector collector
 	docBlock  note.Taker
 	state     decoderState
-	// configure the tokenizer for the next decode
-	UseFloats bool
+	blanks    []token.Pos // blank lines waiting for the next token
 }
 
-type decoderState func(token.Pos, token.Type, any) error
+// each decode gets its own parser.
+// ( a note book refers to its parser until the book is resolved. )
+func (d *Decoder) newParser() (p *parser) {
+	p = &parser{Decoder: d}
+	p.collector = collector{
+		maps:         d.maps,
+		seqs:         d.seqs,
+		sited:        d.sited,
+		narrow:       d.UseNarrowTypes,
+		keepComments: d.notes != nil || d.sidecar != nil,
+		sidecar:      d.sidecar != nil,
+	}
+	p.out.sidecar = d.sidecar
+	p.out.positions = d.positions
+	p.out.hooks = d.hooks
+	switch {
+	case d.notes != nil:
+		p.docBlock = d.notes
+	case d.sidecar != nil:
+		p.docBlock = new(note.Book) // resolved into the sidecar
+	default:
+		p.docBlock = note.Nothing{}
+	}
+	return
+}
 
-func (d *Decoder) Position() (x int, y int) {
-	pos := d.out.pos
-	return pos.X, pos.Y
+func (d *parser) finalizeAll() (ret any, err error) {
+	if ret, err = d.out.finalizeAll(); err == nil && d.out.sidecar != nil {
+		if str, _ := d.docBlock.Resolve(); len(str) > 0 {
+			d.out.sidecar[""] = str
+		}
+	}
+	return
 }
 
+func (d *parser) decode(p charm.Parser) (ret any, err error) {
+	var x, y int
+	d.out.cursor = func() token.Pos { return token.Pos{X: x, Y: y} }
+	run := charm.Parallel("parallel",
+		charmed.FilterInvalidRunes(),
+		d.decodeDoc().Decode(), // tbd: wrap with charmed.UnhandledError()? why/why not.
+		charmed.DecodePos(&y, &x),
+	)
+	if e := p.ParseEof(run); e != nil {
+		err = errorAt(y, x, e)
+	} else {
+		ret, err = d.finalizeAll()
+	}
+	return
+}
+
+type decoderState func(token.Pos, token.Type, any) error
+
 // implements token dispatch, hiding it from the public interface
-type dispatcher struct{ *Decoder }
+type dispatcher struct{ *parser }
 
 // implements the token thingy
-func (dispatch dispatcher) Decoded(at token.Pos, tokenType token.Type, val any) error {
-	return dispatch.state(at, tokenType, val)
+func (dispatch dispatcher) Decoded(at token.Pos, tokenType token.Type, val any) (err error) {
+	out := &dispatch.out
+	if out.raw != nil {
+		out.raw.token(at, out.cursor())
+	}
+	if tokenType == token.Comment && val == "" {
+		// the tokenizer reports blank lines as empty comments;
+		// what they mean depends on the token which follows them.
+		dispatch.blanks = append(dispatch.blanks, at)
+	} else if tokenType == token.Comment && len(dispatch.blanks) == 0 && out.claimComment(at) {
+		// the comment is part of a raw scalar
+	} else if e := out.endRawScalar(); e != nil {
+		err = e
+	} else if e := dispatch.flushBlanks(at, tokenType, val); e != nil {
+		err = e
+	} else {
+		dispatch.out.tokenAt = at
+		err = dispatch.state(at, tokenType, val)
+	}
+	return
 }
 
-func (d *Decoder) decodeDoc() charm.State {
+// record any blank lines which came before the next token.
+// blank lines mustn't end a value that's still pending:
+// between a key and its value, they become a prefix of a collection value,
+// and blank lines before a scalar value are dropped.
+func (d *parser) flushBlanks(next token.Pos, tokenType token.Type, val any) (err error) {
+	if len(d.blanks) > 0 {
+		x, blanks := next.X, d.blanks
+		d.blanks = d.blanks[:0]
+		if d.out.waitingForValue && tokenType != token.Comment {
+			diff := next.X - d.out.pos.X
+			keyAsValue := isMapping(d.out.pendingValue) && val == ""
+			if tokenType != token.Key {
+				blanks = nil
+			} else if diff > 0 || (diff == 0 && keyAsValue) {
+				x = d.out.pos.X + 1
+			}
+		}
+		for _, at := range blanks {
+			at.X = x
+			d.out.tokenAt = at
+			if e := d.state(at, token.Comment, blankLine); e != nil {
+				err = e
+				break
+			}
+		}
+	}
+	return
+}
+
+// the comment text used to record blank lines
+const blankLine = string(runes.BlankLine)
+
+// prepare to decode a new document
+// returns the configuration for reading its tokens.
+func (d *parser) decodeDoc() token.Tokenizer {
 	d.state = d.docStart
 	d.docBlock.BeginCollection(&d.collector.commentContext)
-	t := token.Tokenizer{
-		Notifier:  dispatcher{d},
-		UseFloats: d.UseFloats,
+	return token.Tokenizer{
+		Notifier:    dispatcher{d},
+		UseFloats:   d.UseFloats,
+		NumberMode:  d.NumberMode,
+		UseInfNaN:   d.UseInfNaN,
+		Scalars:     d.Scalars,
+		UseHeredocs: d.UseHeredocs,
+		// blank lines are only useful when keeping comments
+		UseBlankLines: d.UseBlankLines && d.collector.keepComments,
 	}
-	return t.Decode()
 }
 
-func (d *Decoder) docStart(at token.Pos, tokenType token.Type, val any) (err error) {
+func (d *parser) docStart(at token.Pos, tokenType token.Type, val any) (err error) {
 	switch tokenType {
 	case token.Comment:
 		str := val.(string)
@@ -94,7 +270,8 @@ func (d *Decoder) docStart(at token.Pos, tokenType token.Type, val any) (err err
 
 	case token.Key:
 		key := val.(string)
-		d.out.setPending(at, d.collector.newCollection(key))
+		d.out.setPending(at, d.collector.newCollection(d.site(at, key, false)))
+		d.out.key = markKey(at, key)
 		d.out.waitingForValue = true
 		d.state = d.waitForValue
 
@@ -102,14 +279,15 @@ func (d *Decoder) docStart(at token.Pos, tokenType token.Type, val any) (err err
 		if q := val.(rune); q != runes.ArrayOpen {
 			err = charm.InvalidRune(q)
 		} else {
-			d.out.setPending(at, d.collector.newArray())
+			d.out.setPending(at, d.collector.newArray(d.site(at, "", false)))
 			d.out.waitingForValue = true
 			d.state = d.waitForFirstEl
 		}
 
-	case token.Bool, token.Number, token.String:
+	case token.Bool, token.Number, token.String, token.Custom:
 		scalar := pendingScalar{value: val, Taker: d.docBlock}
 		d.out.setPending(at, scalar) // sets doc scalar for "finalizeAll"
+		d.out.lastEnd = d.out.cursor()
 		d.state = d.docSuffix
 
 	default:
@@ -119,7 +297,7 @@ func (d *Decoder) docStart(at token.Pos, tokenType token.Type, val any) (err err
 }
 
 // the document value was written:
-func (d *Decoder) docFooter(at token.Pos, tokenType token.Type, val any) (err error) {
+func (d *parser) docFooter(at token.Pos, tokenType token.Type, val any) (err error) {
 	switch tokenType {
 	case token.Comment:
 		str := val.(string)
@@ -131,7 +309,7 @@ func (d *Decoder) docFooter(at token.Pos, tokenType token.Type, val any) (err er
 }
 
 // the document value has just been decoded, process the comments as a suffix:
-func (d *Decoder) docSuffix(at token.Pos, tokenType token.Type, val any) (err error) {
+func (d *parser) docSuffix(at token.Pos, tokenType token.Type, val any) (err error) {
 	switch tokenType {
 	case token.Comment:
 		if str := val.(string); at.X > d.out.pos.X {
@@ -148,7 +326,7 @@ func (d *Decoder) docSuffix(at token.Pos, tokenType token.Type, val any) (err er
 }
 
 // a value had just been decoded, now we need a new key.
-func (d *Decoder) waitForKey(at token.Pos, tokenType token.Type, val any) (err error) {
+func (d *parser) waitForKey(at token.Pos, tokenType token.Type, val any) (err error) {
 	switch tokenType {
 	default:
 		err = fmt.Errorf("unexpected %s while waiting for key", tokenType)
@@ -178,7 +356,7 @@ func (d *Decoder) waitForKey(at token.Pos, tokenType token.Type, val any) (err e
 }
 
 // a key has just been decoded, now we need a value.
-func (d *Decoder) waitForValue(at token.Pos, tokenType token.Type, val any) (err error) {
+func (d *parser) waitForValue(at token.Pos, tokenType token.Type, val any) (err error) {
 	switch tokenType {
 	default:
 		err = fmt.Errorf("unexpected %s while waiting for value", tokenType)
@@ -189,8 +367,9 @@ func (d *Decoder) waitForValue(at token.Pos, tokenType token.Type, val any) (err
 		keyAsValue := isMapping(d.out.pendingValue) && len(key) == 0
 		//
 		if diff > 0 || (diff == 0 && keyAsValue) {
-			p := d.collector.newCollection(key)
+			p := d.collector.newCollection(d.site(at, key, true))
 			d.out.push(at, p)
+			d.out.key = markKey(at, key)
 		} else {
 			err = d.out.newKey(at, key)
 		}
@@ -202,12 +381,12 @@ func (d *Decoder) waitForValue(at token.Pos, tokenType token.Type, val any) (err
 		if at.X < d.out.pos.X {
 			err = InvalidIndent(d.out.pos, at)
 		} else {
-			p := d.collector.newArray()
+			p := d.collector.newArray(d.site(at, "", true))
 			d.out.push(at, p)
 			d.state = d.waitForFirstEl
 		}
 
-	case token.Bool, token.Number, token.String:
+	case token.Bool, token.Number, token.String, token.Custom:
 		if at.X <= d.out.pos.X {
 			err = InvalidIndent(d.out.pos, at)
 		} else if e := d.out.setValue(val); e != nil {
@@ -219,7 +398,18 @@ func (d *Decoder) waitForValue(at token.Pos, tokenType token.Type, val any) (err
 	return
 }
 
-func (d *Decoder) onComment(at token.Pos, kind note.Type, str string) (err error) {
+// describe where a new collection begins.
+// nested collections are the value of the current element;
+// their paths are only determined if the factories want them.
+func (d *parser) site(at token.Pos, key string, nested bool) collect.Site {
+	site := collect.Site{Key: key, At: at}
+	if nested && d.sited {
+		site.Path = d.out.elementPath()
+	}
+	return site
+}
+
+func (d *parser) onComment(at token.Pos, kind note.Type, str string) (err error) {
 	// a prefix for the still yet to be found value
 	if at.X > d.out.pos.X {
 		err = d.out.addComment(kind, at, str)
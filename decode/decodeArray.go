@@ -10,13 +10,15 @@ import (
 
 // waiting for an array separator, or close.
 // [ 1, 2 .... <-ex. here ]
-func (d *Decoder) waitForSep(at token.Pos, tokenType token.Type, val any) (err error) {
-	if q, ok := val.(rune); !ok {
+func (d *parser) waitForSep(at token.Pos, tokenType token.Type, val any) (err error) {
+	if val == blankLine {
+		// blank lines within arrays are ignored
+	} else if q, ok := val.(rune); !ok {
 		err = fmt.Errorf("%s unexpected", tokenType)
 	} else {
 		switch q {
 		case runes.ArrayClose:
-			d.endArray()
+			err = d.endArray()
 		case runes.ArraySeparator:
 			if e := d.out.setKey(at.Y, ""); e != nil {
 				err = e
@@ -30,7 +32,7 @@ func (d *Decoder) waitForSep(at token.Pos, tokenType token.Type, val any) (err e
 	return
 }
 
-func (d *Decoder) waitForFirstEl(at token.Pos, tokenType token.Type, val any) (err error) {
+func (d *parser) waitForFirstEl(at token.Pos, tokenType token.Type, val any) (err error) {
 	if tokenType == token.Array && val.(rune) == runes.ArrayClose {
 		err = d.endArray()
 	} else {
@@ -42,13 +44,16 @@ func (d *Decoder) waitForFirstEl(at token.Pos, tokenType token.Type, val any) (e
 // wait for the next array element.
 // a separator here, or close, generates an implicit nil.
 // [ 1, 2, .... <- ex. here ]
-func (d *Decoder) waitForEl(at token.Pos, tokenType token.Type, val any) (err error) {
+func (d *parser) waitForEl(at token.Pos, tokenType token.Type, val any) (err error) {
 	switch tokenType {
 	case token.Comment, token.Key:
 		// fix: after cleaning up package notes, then revisit comments in arrays.
-		err = fmt.Errorf("%s not allowed inside arrays", tokenType)
+		// ( blank lines within arrays are ignored )
+		if val != blankLine {
+			err = fmt.Errorf("%s not allowed inside arrays", tokenType)
+		}
 
-	case token.Bool, token.Number, token.String:
+	case token.Bool, token.Number, token.String, token.Custom:
 		err = d.newArrayValue(val)
 
 	case token.Array:
@@ -81,7 +86,7 @@ func (d *Decoder) waitForEl(at token.Pos, tokenType token.Type, val any) (err er
 	return
 }
 
-func (d *Decoder) newArrayValue(val any) (err error) {
+func (d *parser) newArrayValue(val any) (err error) {
 	if e := d.out.setValue(val); e != nil {
 		err = e
 	} else {
@@ -90,14 +95,19 @@ func (d *Decoder) newArrayValue(val any) (err error) {
 	return
 }
 
-func (d *Decoder) endArray() (err error) {
+func (d *parser) endArray() (err error) {
+	at := d.out.tokenAt // the closing bracket
+	d.out.lastEnd = token.Pos{X: at.X + 1, Y: at.Y}
 	// hrm: collections at the doc level
 	// technically never end ( a new key could be coming
 	// right up to the end of the document;
 	if len(d.out.stack) == 0 {
 		d.state = d.docFooter
 	} else {
-		if e := d.out.popTop(); e != nil {
+		d.out.inclusive = true // the closing bracket is part of the array
+		e := d.out.popTop()
+		d.out.inclusive = false
+		if e != nil {
 			err = e
 		} else {
 			d.state = d.waitForKey
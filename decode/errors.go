@@ -1,6 +1,7 @@
 package decode
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/ionous/tell/token"
@@ -38,3 +39,14 @@ func (e ErrorPos) Error() string {
 func ErrorAt(y, x int, err error) ErrorPos {
 	return ErrorPos{y, x, err}
 }
+
+// wrap the passed error with a position, unless it already has one.
+func errorAt(y, x int, err error) (ret error) {
+	var pos ErrorPos
+	if errors.As(err, &pos) {
+		ret = err
+	} else {
+		ret = ErrorAt(y, x, err)
+	}
+	return
+}
@@ -0,0 +1,31 @@
+package decode
+
+import (
+	"github.com/ionous/tell/collect"
+)
+
+// called with each value as it's finalized:
+// scalars as they're read, collections once all of their elements have been read.
+// ( the value of a key without a value is nil. )
+// returns the value to use in its place, or an error to stop decoding.
+// errors are reported at the start of the value.
+type Hook func(path collect.Path, at Span, val any) (any, error)
+
+// add a hook to the end of the decoder's chain of hooks;
+// each hook receives the value returned by the one before.
+func (d *Decoder) AddHook(h Hook) {
+	d.hooks = append(d.hooks, h)
+}
+
+func runHooks(hooks []Hook, path collect.Path, at Span, val any) (ret any, err error) {
+	ret = val
+	for _, h := range hooks {
+		if v, e := h(path, at, ret); e != nil {
+			err = ErrorAt(at.Start.Y, at.Start.X, e)
+			break
+		} else {
+			ret = v
+		}
+	}
+	return
+}
@@ -0,0 +1,128 @@
+package decode_test
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ionous/tell/collect"
+	"github.com/ionous/tell/collect/stdmap"
+	"github.com/ionous/tell/collect/stdseq"
+	"github.com/ionous/tell/decode"
+)
+
+// hooks can replace values as they're decoded.
+func TestHooks(t *testing.T) {
+	const doc = "Home: \"${HOOK_TEST_HOME}/save\"\n" +
+		"When: \"2024-03-01T10:00:00Z\"\n" +
+		"Sizes: [1, 2]\n" +
+		"Empty:\n"
+	t.Setenv("HOOK_TEST_HOME", "/home/player")
+	var dec decode.Decoder
+	dec.SetMapper(stdmap.Make)
+	dec.SetSequencer(stdseq.Make)
+	var paths []string
+	dec.AddHook(func(path collect.Path, at decode.Span, val any) (any, error) {
+		paths = append(paths, string(path))
+		return val, nil
+	})
+	dec.AddHook(func(path collect.Path, at decode.Span, val any) (ret any, err error) {
+		ret = val
+		if str, ok := val.(string); ok {
+			ret = os.ExpandEnv(str)
+		}
+		return
+	})
+	dec.AddHook(func(path collect.Path, at decode.Span, val any) (ret any, err error) {
+		ret = val
+		if str, ok := val.(string); ok && path == "When:" {
+			ret, err = time.Parse(time.RFC3339, str)
+		}
+		return
+	})
+	dec.AddHook(func(path collect.Path, at decode.Span, val any) (ret any, err error) {
+		ret = val
+		if els, ok := val.([]any); ok {
+			ret = len(els) // collections can be replaced too
+		}
+		return
+	})
+	want := map[string]any{
+		"Home:":  "/home/player/save",
+		"When:":  time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		"Sizes:": 2,
+		"Empty:": nil,
+	}
+	// values are visited as they're finished, so the document itself is last.
+	// ( it has the empty path. )
+	if have, e := dec.Decode(strings.NewReader(doc)); e != nil {
+		t.Fatal(e)
+	} else if !reflect.DeepEqual(have, want) {
+		t.Fatalf("have %#v", have)
+	} else if got := strings.Join(paths, ","); got != "Home:,When:,Sizes:/0,Sizes:/1,Sizes:,Empty:," {
+		t.Fatal("unexpected order", got)
+	}
+}
+
+// an error from a hook stops decoding, reporting the position of the value.
+func TestHookErrors(t *testing.T) {
+	const doc = "Levels:\n" +
+		"  - 1\n" +
+		"  - -5\n" +
+		"  - 3\n"
+	negative := errors.New("negative")
+	for i, decodeDoc := range []func(*decode.Decoder) error{
+		func(dec *decode.Decoder) (err error) {
+			_, err = dec.Decode(strings.NewReader(doc))
+			return
+		},
+		func(dec *decode.Decoder) (err error) {
+			_, err = dec.DecodeBytes([]byte(doc))
+			return
+		},
+	} {
+		var dec decode.Decoder
+		dec.SetMapper(stdmap.Make)
+		dec.SetSequencer(stdseq.Make)
+		dec.AddHook(func(path collect.Path, at decode.Span, val any) (ret any, err error) {
+			if n, ok := val.(int); ok && n < 0 {
+				err = negative
+			}
+			return val, err
+		})
+		var pos decode.ErrorPos
+		if e := decodeDoc(&dec); !errors.Is(e, negative) {
+			t.Fatalf("test %d expected an error, have %v", i, e)
+		} else if !errors.As(e, &pos) {
+			t.Fatalf("test %d expected a position", i)
+		} else if y, x := pos.Pos(); y != 2 || x != 4 {
+			t.Fatalf("test %d unexpected position %d,%d", i, y, x)
+		}
+	}
+}
+
+// hooks can reject the implicit nil of a key without a value.
+func TestHookMissingValues(t *testing.T) {
+	missing := errors.New("missing")
+	for _, doc := range []string{
+		"a:\nb: 1\n", // nil before another key
+		"a: 1\nb:\n", // nil at the end of the document
+	} {
+		var dec decode.Decoder
+		dec.SetMapper(stdmap.Make)
+		dec.SetSequencer(stdseq.Make)
+		dec.AddHook(func(path collect.Path, at decode.Span, val any) (any, error) {
+			var err error
+			if val == nil {
+				err = missing
+			}
+			return val, err
+		})
+		if _, e := dec.Decode(strings.NewReader(doc)); !errors.Is(e, missing) {
+			t.Errorf("%q expected an error, have %v", doc, e)
+		}
+	}
+}
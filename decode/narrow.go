@@ -0,0 +1,80 @@
+package decode
+
+// if every element of a []any has the same basic type,
+// return a slice of that type instead.
+// ( ex. []string, []int, []float64, or []bool )
+func narrowSequence(v any) (ret any) {
+	ret = v
+	if els, ok := v.([]any); ok && len(els) > 0 {
+		switch els[0].(type) {
+		case string:
+			ret = narrowSlice[string](els)
+		case int:
+			ret = narrowSlice[int](els)
+		case float64:
+			ret = narrowSlice[float64](els)
+		case bool:
+			ret = narrowSlice[bool](els)
+		}
+	}
+	return
+}
+
+// if every value of a map[string]any has the same basic type,
+// return a map of that type instead.
+// ( ex. map[string]string )
+func narrowMapping(v any) (ret any) {
+	ret = v
+	if m, ok := v.(map[string]any); ok {
+		var first any
+		for _, el := range m {
+			first = el // any value will do
+			break
+		}
+		switch first.(type) {
+		case string:
+			ret = narrowMap[string](m)
+		case int:
+			ret = narrowMap[int](m)
+		case float64:
+			ret = narrowMap[float64](m)
+		case bool:
+			ret = narrowMap[bool](m)
+		}
+	}
+	return
+}
+
+// returns the original slice if any of its elements aren't a T
+func narrowSlice[T any](els []any) (ret any) {
+	out := make([]T, len(els))
+	for i, el := range els {
+		if v, ok := el.(T); !ok {
+			out = nil
+			break
+		} else {
+			out[i] = v
+		}
+	}
+	if ret = els; out != nil {
+		ret = out
+	}
+	return
+}
+
+// returns the original map if any of its values aren't a T
+func narrowMap[T any](m map[string]any) (ret any) {
+	out := make(map[string]T, len(m))
+	for k, el := range m {
+		if v, ok := el.(T); !ok {
+			out = nil
+			break
+		} else {
+			out[k] = v
+		}
+	}
+	if ret = m; out != nil {
+		ret = out
+	}
+	return
+}
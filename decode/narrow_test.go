@@ -0,0 +1,58 @@
+package decode_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell/collect/stdmap"
+	"github.com/ionous/tell/collect/stdseq"
+	"github.com/ionous/tell/decode"
+)
+
+// homogeneous collections get narrowed; everything else stays as is.
+func TestNarrowTypes(t *testing.T) {
+	const doc = "Names:\n" +
+		"  - \"a\"\n" +
+		"  - \"b\"\n" +
+		"Ints: [1, 2, 3]\n" +
+		"Floats: [1.5, 2.5]\n" +
+		"Flags:\n" +
+		"  - true\n" +
+		"  - false\n" +
+		"Mixed: [1, \"two\"]\n" +
+		"Holes:\n" +
+		"  - 1\n" +
+		"  -\n" +
+		"  - 3\n" +
+		"Empty: []\n" +
+		"Labels:\n" +
+		"  north: \"door\"\n" +
+		"  south: \"wall\"\n" +
+		"Nested:\n" +
+		"  - [1, 2]\n" +
+		"  - [3]\n"
+	var dec decode.Decoder
+	dec.SetMapper(stdmap.Make)
+	dec.SetSequencer(stdseq.Make)
+	dec.UseNarrowTypes = true
+	want := map[string]any{
+		"Names:":  []string{"a", "b"},
+		"Ints:":   []int{1, 2, 3},
+		"Floats:": []float64{1.5, 2.5},
+		"Flags:":  []bool{true, false},
+		"Mixed:":  []any{1, "two"},
+		"Holes:":  []any{1, nil, 3},
+		"Empty:":  []any{},
+		"Labels:": map[string]string{
+			"north:": "door",
+			"south:": "wall",
+		},
+		"Nested:": []any{[]int{1, 2}, []int{3}},
+	}
+	if have, e := dec.Decode(strings.NewReader(doc)); e != nil {
+		t.Fatal(e)
+	} else if !reflect.DeepEqual(have, want) {
+		t.Fatalf("have %#v", have)
+	}
+}
@@ -3,6 +3,7 @@ package decode
 import (
 	"errors"
 
+	"github.com/ionous/tell/collect"
 	"github.com/ionous/tell/note"
 	"github.com/ionous/tell/token"
 )
@@ -11,22 +12,48 @@ type output struct {
 	pendingAt
 	stack           pendingStack
 	waitingForValue bool
-	skipTerm        bool // ie. if its already been processed
+	skipTerm        bool             // ie. if its already been processed
+	sidecar         note.Sidecar     // when set, receives the comment blocks of collections.
+	raw             *rawSource       // when set, replaces matching values with their source text.
+	rawScalar       *rawScalar       // a raw scalar waiting for its comments.
+	tokenAt         token.Pos        // the position of the token being processed
+	positions       Positions        // when set, receives the position of every value.
+	cursor          func() token.Pos // the position of the decoder; after a scalar token, its end.
+	lastEnd         token.Pos        // the end of the most recent value
+	inclusive       bool             // the current token is part of the collection being closed.
+	hooks           []Hook           // when set, called with every value.
+}
+
+// are the positions of values needed?
+func (out *output) tracking() bool {
+	return out.raw != nil || out.positions != nil || len(out.hooks) > 0
 }
 
 func (out *output) finalizeAll() (ret any, err error) {
-	if _, e := out.uncheckedPop(-1); e != nil {
+	out.inclusive = true // everything left belongs to the open collections
+	if e := out.endRawScalar(); e != nil {
 		err = e
-	} else {
-		if out.pendingValue != nil { // tbd: error on empty document?
-			ret = out.finalize()
+	} else if out.tracking() && out.waitingForValue && out.key.valid {
+		err = out.setNil() // the last key of the document has no value
+	}
+	if err != nil {
+		// the last value was rejected
+	} else if _, e := out.uncheckedPop(-1); e != nil {
+		err = e
+	} else if out.pendingValue != nil { // tbd: error on empty document?
+		ret = out.finalizeTop()
+		if out.raw != nil && out.raw.match("") {
+			ret = out.raw.slice(0, out.raw.read())
+		}
+		if out.tracking() {
+			ret, err = out.place("", keyMark{}, out.start, out.lastEnd, ret)
 		}
 	}
 	return
 }
 
 func (out *output) setPending(at token.Pos, p pendingValue) {
-	out.pendingAt = pendingAt{pos: at, pendingValue: p}
+	out.pendingAt = pendingAt{pos: at, start: at, pendingValue: p}
 }
 
 func (out *output) push(at token.Pos, p pendingValue) {
@@ -60,6 +87,7 @@ func (out *output) newKey(at token.Pos, key string) (err error) {
 	} else if e := out.setKey(at.Y, key); e != nil {
 		err = e
 	} else {
+		out.key = markKey(at, key)
 		out.newTerm()
 		out.skipTerm = false
 	}
@@ -86,9 +114,44 @@ func (out *output) addComment(baseType note.Type, at token.Pos, str string) erro
 	return out.Comment(noteType, str)
 }
 
+// set the value of the pending element to a scalar.
 func (out *output) setValue(val any) (err error) {
 	out.waitingForValue = false
-	return out.pendingAt.setValue(val)
+	var path collect.Path
+	if out.tracking() {
+		path = out.elementPath()
+	}
+	if out.raw != nil && out.raw.matches(path, out.key) {
+		// the scalar's token has been read in full, but its comments haven't.
+		// ( see endRawScalar )
+		out.rawScalar = &rawScalar{path, out.key, out.tokenAt, out.cursor(), out.raw.read()}
+	} else {
+		if out.tracking() {
+			val, err = out.place(path, out.key, out.tokenAt, out.cursor(), val)
+		}
+		if err == nil {
+			err = out.pendingAt.setValue(val)
+		}
+	}
+	return
+}
+
+// the pending element has no value.
+func (out *output) setNil() (err error) {
+	out.waitingForValue = false
+	var val any
+	if out.tracking() {
+		path := out.elementPath()
+		if out.raw != nil { // its text ends where the current token starts
+			val = out.rawValue(path, out.key, val, out.raw.offset(out.tokenAt))
+		}
+		end := out.key.end()
+		val, err = out.place(path, out.key, end, end, val)
+	}
+	if err == nil {
+		err = out.pendingAt.setValue(val)
+	}
+	return
 }
 
 // internal: find the collection indicated by the passed indentation:
@@ -96,9 +159,11 @@ func (out *output) setValue(val any) (err error) {
 // generates an implicit nil if needed
 func (out *output) popToIndent(at int) (err error) {
 	if out.waitingForValue {
-		out.setValue(nil)
+		err = out.setNil()
 	}
-	if cnt, e := out.uncheckedPop(at); e != nil {
+	if err != nil {
+		// the implicit nil was rejected
+	} else if cnt, e := out.uncheckedPop(at); e != nil {
 		err = e
 	} else if cnt > 0 && at != out.pos.X {
 		err = errors.New("mismatched indent")
@@ -117,15 +182,68 @@ func (out *output) uncheckedPop(at int) (ret int, err error) {
 	return
 }
 
+// finalize the current pending value;
+// moving its comments to the sidecar ( if there is one. )
+func (out *output) finalizeTop() any {
+	if out.sidecar != nil {
+		// resolving the comments here keeps them out of the collection
+		if str, ok := out.Resolve(); ok && len(str) > 0 {
+			out.sidecar[out.path()] = str
+		}
+	}
+	return out.finalize()
+}
+
+// the path of the current pending value
+func (out *output) path() (ret collect.Path) {
+	for _, el := range out.stack {
+		switch p := el.pendingValue.(type) {
+		case *pendingMap:
+			ret = ret.Key(p.key)
+		case *pendingSeq:
+			ret = ret.Index(p.index - p.first)
+		}
+	}
+	return
+}
+
+// the path of the element waiting for a value in the current collection.
+func (out *output) elementPath() (ret collect.Path) {
+	ret = out.path()
+	switch p := out.pendingValue.(type) {
+	case *pendingMap:
+		ret = ret.Key(p.key)
+	case *pendingSeq:
+		ret = ret.Index(p.index - p.first)
+	}
+	return
+}
+
 // end the current collection or array.
 func (out *output) popTop() (err error) {
 	out.EndCollection()
-	prev := out.finalize()  // finalize the current pending value
+	prev := out.finalizeTop() // finalize the current pending value
+	key := out.stack[len(out.stack)-1].key
+	if out.raw != nil {
+		// the collection ends where the current token starts
+		// ( or after it, if the token closes the collection. )
+		end := out.raw.read()
+		if !out.inclusive {
+			end = out.raw.offset(out.tokenAt)
+		}
+		prev = out.rawValue(out.path(), key, prev, end)
+	}
+	if out.tracking() {
+		prev, err = out.place(out.path(), key, out.start, out.lastEnd, prev)
+	}
 	next := out.stack.pop() // move this to pending
-	if e := next.setValue(prev); e != nil {
+	if err != nil {
+		// the collection was rejected
+	} else if e := next.setValue(prev); e != nil {
 		err = e
 	} else {
 		out.pendingAt = next
+		out.waitingForValue = false // ex. an empty array never set an element
 	}
 	return
 }
@@ -5,7 +5,9 @@ import (
 )
 
 type pendingAt struct {
-	pos token.Pos
+	pos   token.Pos // the indent of the value; the row changes with each new key.
+	start token.Pos // where the value started
+	key   keyMark   // the key of the element waiting for a value
 	pendingValue
 }
 
@@ -0,0 +1,54 @@
+package decode
+
+import (
+	"unicode/utf8"
+
+	"github.com/ionous/tell/collect"
+	"github.com/ionous/tell/token"
+)
+
+// Span records where a value appeared in its document.
+// ( ex. for reporting problems found after decoding: ErrorAt(span.Start.Y, span.Start.X, e) )
+type Span struct {
+	Key   token.Pos // the key ( or dash ) of the value; zero for the document, and for the elements of arrays.
+	Start token.Pos // the first rune of the value; for a mapping or sequence, its first key or dash.
+	End   token.Pos // just past the last rune of the value; for an empty value, the end of its key.
+}
+
+// Positions maps the paths of decoded values to their locations.
+// see Decoder.UsePositions
+type Positions map[collect.Path]Span
+
+// the location of the value at the path formed by the passed keys and indices;
+// ex. Of("Enemies:", 3, "Type:")
+// returns false if there was no such value.
+func (p Positions) Of(parts ...any) (ret Span, okay bool) {
+	ret, okay = p[collect.MakePath(parts...)]
+	return
+}
+
+// pass a valid table to record the position of every key and value.
+// a nil disables recording.
+func (d *Decoder) UsePositions(p Positions) {
+	d.positions = p
+}
+
+// record the location of a value, and pass the value through the hooks.
+// returns the value to use in its place.
+func (out *output) place(path collect.Path, key keyMark, start, end token.Pos, val any) (ret any, err error) {
+	span := Span{Key: key.at, Start: start, End: end}
+	out.lastEnd = end
+	if out.positions != nil {
+		out.positions[path] = span
+	}
+	return runHooks(out.hooks, path, span, val)
+}
+
+// the position just past the key
+func (k keyMark) end() token.Pos {
+	n := 1 // a dash
+	if len(k.key) > 0 {
+		n = utf8.RuneCountInString(k.key)
+	}
+	return token.Pos{X: k.at.X + n, Y: k.at.Y}
+}
@@ -0,0 +1,77 @@
+package decode_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell/collect"
+	"github.com/ionous/tell/collect/stdmap"
+	"github.com/ionous/tell/collect/stdseq"
+	"github.com/ionous/tell/decode"
+	"github.com/ionous/tell/token"
+)
+
+// record the position of every key and value;
+// reading from a stream and from bytes should give the same results.
+func TestPositions(t *testing.T) {
+	const doc = "Name: \"game\"\n" +
+		"Enemies:\n" +
+		"  - Type: \"goblin\"\n" +
+		"    Spots: [1, 22]\n" +
+		"  - Type: |\n" +
+		"      dragon\n" +
+		"      '''\n" +
+		"    Boss:\n" +
+		"Level: 5 # comment\n"
+	type span = decode.Span
+	want := map[string]span{
+		"":                    {Start: at(0, 0), End: at(8, 8)},
+		"Name:":               {Key: at(0, 0), Start: at(6, 0), End: at(12, 0)},
+		"Enemies:":            {Key: at(0, 1), Start: at(2, 2), End: at(9, 7)},
+		"Enemies:/0":          {Key: at(2, 2), Start: at(4, 2), End: at(18, 3)},
+		"Enemies:/0/Type:":    {Key: at(4, 2), Start: at(10, 2), End: at(18, 2)},
+		"Enemies:/0/Spots:":   {Key: at(4, 3), Start: at(11, 3), End: at(18, 3)},
+		"Enemies:/0/Spots:/0": {Start: at(12, 3), End: at(13, 3)},
+		"Enemies:/0/Spots:/1": {Start: at(15, 3), End: at(17, 3)},
+		"Enemies:/1":          {Key: at(2, 4), Start: at(4, 4), End: at(9, 7)},
+		"Enemies:/1/Type:":    {Key: at(4, 4), Start: at(10, 4), End: at(9, 6)},
+		"Enemies:/1/Boss:":    {Key: at(4, 7), Start: at(9, 7), End: at(9, 7)},
+		"Level:":              {Key: at(0, 8), Start: at(7, 8), End: at(8, 8)},
+	}
+	for i, decodeDoc := range []func(*decode.Decoder) error{
+		func(dec *decode.Decoder) (err error) {
+			_, err = dec.Decode(strings.NewReader(doc))
+			return
+		},
+		func(dec *decode.Decoder) (err error) {
+			_, err = dec.DecodeBytes([]byte(doc))
+			return
+		},
+	} {
+		var dec decode.Decoder
+		dec.SetMapper(stdmap.Make)
+		dec.SetSequencer(stdseq.Make)
+		ps := make(decode.Positions)
+		dec.UsePositions(ps)
+		if e := decodeDoc(&dec); e != nil {
+			t.Fatal(e)
+		}
+		for path, w := range want {
+			if have, ok := ps[collect.Path(path)]; !ok {
+				t.Errorf("%d: missing %q", i, path)
+			} else if have != w {
+				t.Errorf("%d: %q\nhave %+v\nwant %+v", i, path, have, w)
+			}
+		}
+		if len(ps) != len(want) {
+			t.Errorf("%d: have %d positions, want %d", i, len(ps), len(want))
+		}
+		if s, ok := ps.Of("Enemies:", 1, "Type:"); !ok || s.Start.Y != 4 {
+			t.Errorf("%d: unexpected lookup %v", i, s)
+		}
+	}
+}
+
+func at(x, y int) token.Pos {
+	return token.Pos{X: x, Y: y}
+}
@@ -0,0 +1,196 @@
+package decode
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"github.com/ionous/tell/charmed"
+	"github.com/ionous/tell/collect"
+	"github.com/ionous/tell/runes"
+	"github.com/ionous/tell/token"
+)
+
+// the source text of a document, for producing raw values.
+type rawSource struct {
+	match  func(collect.Path) bool
+	text   []byte       // the document, or as much of it as has been read.
+	read   func() int   // byte offset of the next unread rune.
+	lines  []int        // byte offset of the start of each line; grows as needed.
+	inside map[int]bool // byte offsets of lines inside of tokens ( see token() )
+}
+
+// records the runes of a stream as they are read.
+type recorder struct {
+	io.RuneReader
+	src *rawSource
+}
+
+func (r recorder) ReadRune() (q rune, size int, err error) {
+	if q, size, err = r.RuneReader.ReadRune(); err == nil {
+		r.src.text = utf8.AppendRune(r.src.text, q)
+	}
+	return
+}
+
+// prepare to record the passed stream, if raw values were requested.
+func (d *parser) record(src io.RuneReader) (ret io.RuneReader) {
+	if d.RawValues == nil {
+		d.out.raw, ret = nil, src
+	} else {
+		raw := &rawSource{match: d.RawValues}
+		raw.read = func() int { return len(raw.text) }
+		d.out.raw, ret = raw, recorder{src, raw}
+	}
+	return
+}
+
+// the byte offset of the passed position
+// ( positions count runes from the start of their line. )
+func (src *rawSource) offset(at token.Pos) (ret int) {
+	for start := 0; len(src.lines) <= at.Y; {
+		if n := len(src.lines); n > 0 {
+			if i := bytes.IndexByte(src.text[src.lines[n-1]:], '\n'); i < 0 {
+				break // the line hasn't been read yet
+			} else {
+				start = src.lines[n-1] + i + 1
+			}
+		}
+		src.lines = append(src.lines, start)
+	}
+	if at.Y < len(src.lines) {
+		ret = src.lines[at.Y]
+		for i := 0; i < at.X && ret < len(src.text); i++ {
+			_, size := utf8.DecodeRune(src.text[ret:])
+			ret += size
+		}
+	} else {
+		ret = len(src.text)
+	}
+	return
+}
+
+// the text between the passed offsets as a standalone document:
+// without the leading spaces of its first line, or its trailing whitespace.
+// a value which starts on the line after its key gets shifted left by the indent of that line;
+// lines inside of multi-line tokens ( ex. raw strings and heredocs ) are left as is.
+func (src *rawSource) slice(start, end int) charmed.RawValue {
+	text := bytes.TrimRight(src.text[start:end], " \n")
+	lines := bytes.Split(text, []byte{'\n'})
+	var indent int // the column of the value
+	if first := bytes.TrimLeft(lines[0], " "); len(lines) > 1 && (len(first) == 0 || first[0] == runes.Hash) {
+		// the value started on the line after its key ( possibly after a comment )
+		next := lines[1]
+		indent = len(next) - len(bytes.TrimLeft(next, " "))
+		if len(first) == 0 {
+			start += len(lines[0]) + 1
+			lines = lines[1:]
+		}
+	} else {
+		// the value started on the same line as its key
+		lineStart := bytes.LastIndexByte(src.text[:start], '\n') + 1
+		lead := len(lines[0]) - len(first)
+		indent = utf8.RuneCount(src.text[lineStart : start+lead])
+	}
+	out := append(charmed.RawValue{}, bytes.TrimLeft(lines[0], " ")...)
+	at := start + len(lines[0]) + 1 // the offset of each line
+	for _, line := range lines[1:] {
+		out = append(out, '\n')
+		if !src.inside[at] {
+			n := len(line) - len(bytes.TrimLeft(line, " "))
+			out = append(out, line[min(n, indent):]...)
+		} else {
+			out = append(out, line...)
+		}
+		at += len(line) + 1
+	}
+	return out
+}
+
+// record the lines which are inside of a token
+// ( all but the first line of a token which spans several lines. )
+// their leading spaces are part of the token, so they can't be shifted.
+func (src *rawSource) token(start, end token.Pos) {
+	for y := start.Y + 1; y <= end.Y; y++ {
+		if src.inside == nil {
+			src.inside = make(map[int]bool)
+		}
+		src.inside[src.offset(token.Pos{Y: y})] = true
+	}
+}
+
+// if the passed element should be raw, return its text;
+// otherwise return its decoded value.
+// the text starts after the element's key, and ends at the passed offset.
+func (out *output) rawValue(path collect.Path, key keyMark, val any, end int) (ret any) {
+	ret = val
+	if out.raw.matches(path, key) {
+		ret = out.raw.keyed(key, end)
+	}
+	return
+}
+
+// should the element with the passed path and key be raw?
+// ( elements without keys, ex. the elements of arrays, are never raw. )
+func (src *rawSource) matches(path collect.Path, key keyMark) bool {
+	return key.valid && src.match(path)
+}
+
+// the text of an element, from after its key to the passed offset.
+func (src *rawSource) keyed(key keyMark, end int) charmed.RawValue {
+	start := src.offset(key.at) + key.size()
+	return src.slice(start, max(start, end))
+}
+
+// a raw scalar waits for any comments which follow it on the same line or indented below it;
+// those are part of its text.
+type rawScalar struct {
+	path       collect.Path
+	key        keyMark
+	start, end token.Pos // the position of the scalar itself
+	text       int       // the end of its text; grows with each comment
+}
+
+// claim the passed comment for the pending raw scalar ( if any )
+// returns true if claimed.
+func (out *output) claimComment(at token.Pos) (okay bool) {
+	if s := out.rawScalar; s != nil && at.X > out.pos.X {
+		s.text = out.raw.read()
+		okay = true
+	}
+	return
+}
+
+// store the pending raw scalar ( if any ) in its collection.
+func (out *output) endRawScalar() (err error) {
+	if s := out.rawScalar; s != nil {
+		out.rawScalar = nil
+		val, e := out.place(s.path, s.key, s.start, s.end, out.raw.keyed(s.key, s.text))
+		if e != nil {
+			err = e
+		} else {
+			err = out.pendingAt.setValue(val)
+		}
+	}
+	return
+}
+
+// the position and text of the key ( or dash ) of the pending element.
+// invalid for elements without keys; ex. the elements of arrays.
+type keyMark struct {
+	at    token.Pos
+	key   string
+	valid bool
+}
+
+func markKey(at token.Pos, key string) keyMark {
+	return keyMark{at, key, true}
+}
+
+// the length of the key in bytes
+func (k keyMark) size() (ret int) {
+	if ret = len(k.key); ret == 0 {
+		ret = 1 // a dash
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package decode_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell/collect"
+	"github.com/ionous/tell/collect/imap"
+	"github.com/ionous/tell/collect/stdmap"
+	"github.com/ionous/tell/collect/stdseq"
+	"github.com/ionous/tell/decode"
+)
+
+// the factories can pick collections based on where they appear.
+func TestSites(t *testing.T) {
+	const doc = "Rooms:\n" +
+		"  - Name: \"hall\"\n" +
+		"    Exits: [\"north\"]\n" +
+		"Start: \"hall\"\n"
+	var dec decode.Decoder
+	var sites []collect.Site
+	dec.SetMapperAt(func(site collect.Site, reserve bool) collect.MapWriter {
+		sites = append(sites, site)
+		if len(site.Path) == 0 {
+			return imap.Make(reserve) // keep the order of the top level
+		}
+		return stdmap.Make(reserve)
+	})
+	dec.SetSequencerAt(func(site collect.Site, reserve bool) collect.SequenceWriter {
+		sites = append(sites, site)
+		return stdseq.Make(reserve)
+	})
+	want := imap.ItemMap{
+		{Key: "Rooms:", Value: []any{
+			map[string]any{
+				"Name:":  "hall",
+				"Exits:": []any{"north"},
+			},
+		}},
+		{Key: "Start:", Value: "hall"},
+	}
+	wantSites := []collect.Site{
+		{Path: "", Key: "Rooms:", At: at(0, 0)},
+		{Path: "Rooms:", Key: "", At: at(2, 1)},
+		{Path: "Rooms:/0", Key: "Name:", At: at(4, 1)},
+		{Path: "Rooms:/0/Exits:", Key: "", At: at(11, 2)},
+	}
+	if have, e := dec.Decode(strings.NewReader(doc)); e != nil {
+		t.Fatal(e)
+	} else if !reflect.DeepEqual(have, want) {
+		t.Fatalf("have %#v", have)
+	} else if !reflect.DeepEqual(sites, wantSites) {
+		t.Fatalf("have sites %#v", sites)
+	}
+}
@@ -2,18 +2,33 @@ package tell
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	r "reflect"
 
+	"github.com/ionous/tell/charmed"
 	"github.com/ionous/tell/collect"
 	"github.com/ionous/tell/collect/stdmap"
 	"github.com/ionous/tell/collect/stdseq"
 	"github.com/ionous/tell/decode"
 	"github.com/ionous/tell/note"
+	"github.com/ionous/tell/token"
 )
 
+// Number holds the text of a number exactly as it was written.
+// see Decoder.UseNumber()
+type Number = charmed.Number
+
+// Heredoc holds a multiline string along with its language and closing tag.
+// see Decoder.UseHeredocs()
+type Heredoc = charmed.Heredoc
+
+// RawValue holds the source text of a value, rather than its decoded contents.
+// see Decoder.UseRawValues()
+type RawValue = charmed.RawValue
+
 // Decoder - follows the pattern of encoding/json
 type Decoder struct {
 	src   io.RuneReader
@@ -50,6 +65,19 @@ func (d *Decoder) SetSequencer(seq collect.SequenceFactory) {
 	d.inner.SetSequencer(seq)
 }
 
+// control the creation of mappings for the upcoming Decode
+// based on where each appears in the document.
+// ( ex. imap.Make for the top level, and stdmap.Make elsewhere. )
+func (d *Decoder) SetMapperAt(maps collect.MapSiteFactory) {
+	d.inner.SetMapperAt(maps)
+}
+
+// control the creation of sequences for the upcoming Decode
+// based on where each appears in the document.
+func (d *Decoder) SetSequencerAt(seq collect.SequenceSiteFactory) {
+	d.inner.SetSequencerAt(seq)
+}
+
 // pass a valid target for collecting document level comments
 // during an upcoming call to Decode.
 // the default behavior is to discard comments.
@@ -58,21 +86,125 @@ func (d *Decoder) UseNotes(b *note.Book) {
 	d.inner.UseNotes(b)
 }
 
+// pass a valid table for collecting comments during an upcoming call to Decode.
+// rather than storing comments in the decoded collections
+// ( making sequences one-indexed, and adding blank keys to mappings )
+// each comment block is stored in the table using the path of its collection.
+// see also: Encoder.SetSidecar
+func (d *Decoder) UseSidecar(s note.Sidecar) {
+	d.inner.UseSidecar(s)
+}
+
+// when comments are being kept ( see UseNotes )
+// configure the upcoming Decode to record blank lines in the comment blocks.
+// the encoder writes them back out, keeping the layout of the original document.
+// ( blank lines between a key and a scalar value are dropped. )
+func (d *Decoder) UseBlankLines() {
+	d.inner.UseBlankLines = true
+}
+
 // configure the upcoming Decode to produce only floating point numbers.
 // otherwise it will produce int for integers, and unit for hex specifications.
 func (d *Decoder) UseFloats() {
 	d.inner.UseFloats = true
 }
 
+// configure the upcoming Decode to allow inf, +inf, -inf, and nan as numbers.
+// otherwise, as per json, they are an error.
+func (d *Decoder) UseInfNaN() {
+	d.inner.UseInfNaN = true
+}
+
+// add custom scalar values ( ex. dates, or colors ) to the upcoming Decode.
+// they are tried in order, before the standard values;
+// so a scalar which accepts a plain number hides that number. ( see token.TextScalar )
+// see also: Encoder.SetFormatter
+func (d *Decoder) UseScalars(fs ...token.ScalarFactory) {
+	d.inner.Scalars = append(d.inner.Scalars, fs...)
+}
+
+// configure the upcoming Decode to produce a Heredoc for every heredoc,
+// keeping the language ( ex. ```go ) and custom closing tag of its header.
+// the encoder writes Heredocs using the same header.
+func (d *Decoder) UseHeredocs() {
+	d.inner.UseHeredocs = true
+}
+
+// configure the upcoming Decode to produce a Number for every number,
+// holding the text of the number exactly as it was written.
+// ( this overrides UseFloats )
+// the encoder writes Numbers using that same text,
+// so hex values, exponents, etc. survive a round trip.
+func (d *Decoder) UseNumber() {
+	d.inner.NumberMode = charmed.NumberText
+}
+
+// configure the upcoming Decode to produce *big.Int or *big.Float
+// for numbers too large to fit their usual types.
+// otherwise, such numbers are an error.
+func (d *Decoder) UseBigNumbers() {
+	d.inner.NumberMode = charmed.NumberBig
+}
+
+// pass a valid table to record the position of every key and value
+// during an upcoming call to Decode; indexed by the path of each value.
+// ( ex. for reporting problems found after decoding. )
+// passing nil disables recording.
+func (d *Decoder) UsePositions(p decode.Positions) {
+	d.inner.UsePositions(p)
+}
+
+// add a function to call with each value as it's decoded.
+// it can replace the value, or reject it with an error reported at the value's position.
+// ( ex. to expand environment variables, or to convert strings into times. )
+// hooks are called in the order they were added.
+func (d *Decoder) AddHook(h decode.Hook) {
+	d.inner.AddHook(h)
+}
+
+// configure the upcoming Decode to produce typed slices and maps
+// when all of a collection's values have the same type:
+// ex. []string or []int rather than []any; map[string]bool rather than map[string]any.
+// ( collections which keep comments are left as is. )
+func (d *Decoder) UseNarrowTypes() {
+	d.inner.UseNarrowTypes = true
+}
+
+// configure the upcoming Decode to produce a RawValue for values at matching paths:
+// the source text of the value, including its comments.
+// ( the lines of a value written below its key are shifted left to the value's own indent;
+// the lines inside of raw strings and heredocs are kept exactly as written. )
+// the text can be decoded on its own later, or written back out as is by the encoder.
+// ( decoding into a *RawValue captures the whole document. )
+func (d *Decoder) UseRawValues(match func(collect.Path) bool) {
+	d.inner.RawValues = match
+}
+
 // read a tell document from the stream configured in NewDecoder,
 // and store the result at the value pointed by pv.
 func (dec *Decoder) Decode(pv any) (err error) {
+	return dec.decode(pv, func() (any, error) {
+		return dec.inner.Decode(dec.src)
+	})
+}
+
+// read a tell document from the stream configured in NewDecoder,
+// and store the result at the value pointed by pv.
+// stops early if the context is canceled or times out,
+// returning the context's error wrapped with the position of the decoder.
+func (dec *Decoder) DecodeContext(ctx context.Context, pv any) (err error) {
+	return dec.decode(pv, func() (any, error) {
+		return dec.inner.DecodeContext(ctx, dec.src)
+	})
+}
+
+func (dec *Decoder) decode(pv any, decode func() (any, error)) (err error) {
 	out := r.ValueOf(pv)
 	if out.Kind() != r.Pointer || out.IsNil() {
 		err = &InvalidUnmarshalError{r.TypeOf(pv)}
 	} else if out := out.Elem(); !out.CanSet() {
 		err = errors.New("expected a settable value")
-	} else if raw, e := dec.inner.Decode(dec.src); e != nil {
+	} else if raw, e := dec.decodeRaw(out.Type(), decode); e != nil {
 		err = e
 	} else if raw == nil {
 		out.SetZero()
@@ -89,6 +221,18 @@ func (dec *Decoder) Decode(pv any) (err error) {
 	return
 }
 
+// decoding into a RawValue captures the whole document.
+func (dec *Decoder) decodeRaw(t r.Type, decode func() (any, error)) (any, error) {
+	if t == rawType {
+		prev := dec.inner.RawValues
+		dec.inner.RawValues = func(p collect.Path) bool { return len(p) == 0 }
+		defer func() { dec.inner.RawValues = prev }()
+	}
+	return decode()
+}
+
+var rawType = r.TypeOf(RawValue(nil))
+
 // As per package encoding/json, describes an invalid argument passed to Unmarshal or Decode.
 // Arguments must be non-nil pointers
 type InvalidUnmarshalError struct {
@@ -8,6 +8,9 @@ import (
 	r "reflect"
 	"strings"
 
+	"github.com/ionous/tell/charmed"
+	"github.com/ionous/tell/collect"
+	"github.com/ionous/tell/note"
 	"github.com/ionous/tell/runes"
 )
 
@@ -38,117 +41,171 @@ type Encoder struct {
 	Mapper, Sequencer StartCollection
 	MapComments       Commenting
 	SequenceComments  Commenting
+	QuoteStyle        QuoteStyle // how to write strings; iterators can override this.
+	UseInfNaN         bool       // write inf, -inf, and nan; otherwise they are an error.
+	Formatters        map[r.Type]Formatter
+	// comment blocks keyed by the path of their collection;
+	// used for collections which don't have their own comments.
+	Sidecar note.Sidecar
+	path    collect.Path // the path of the value being written
+	// comments for the upcoming collection, from TellComments or TellKeyComments
+	pending Comments
 }
 
+// writes a value of a specific type as an unquoted scalar.
+// the text should be something the decoder can read back
+// ( ex. using a token.ScalarFactory )
+type Formatter func(r.Value) (string, error)
+
+// register a formatter for values of the passed type.
+func (enc *Encoder) SetFormatter(t r.Type, f Formatter) {
+	if enc.Formatters == nil {
+		enc.Formatters = make(map[r.Type]Formatter)
+	}
+	enc.Formatters[t] = f
+}
+
+// write a value as a tell document.
 func (enc *Encoder) Encode(v any) (err error) {
-	if e := enc.WriteValue(r.ValueOf(v), false); e != nil {
+	var block string
+	if val := r.ValueOf(v); enc.Sidecar != nil && !enc.isCollection(val) {
+		// the comments of a document scalar
+		// ( a document collection uses the root entry as its own comment block. )
+		block = enc.Sidecar[""]
+	}
+	return enc.EncodeWithComments(v, block)
+}
+
+// write a document, with comments.
+// the block uses the same format the decoder uses for document comments:
+// header lines, an optional inline or trailing suffix ( for a scalar value ),
+// then a form feed and any footer lines.
+func (enc *Encoder) EncodeWithComments(v any, block string) (err error) {
+	tab := &enc.Tabs
+	var cmt, footer Comment
+	if len(block) > 0 {
+		it := commentBlock{rest: block}
+		if it.Next() {
+			cmt = it.GetComment()
+		}
+		if it.Next() {
+			footer = it.GetComment()
+		}
+	}
+	tab.writeLines(cmt.Header)
+	if e := enc.writeValue(r.ValueOf(v), false, enc.QuoteStyle); e != nil {
 		err = e
 	} else {
+		if suffix := cmt.Suffix; len(suffix) > 0 {
+			fixedWrite(tab, suffix)
+		}
+		tab.Softline()
+		tab.writeLines(footer.Header)
 		// ends with an artificial newline
 		// fwiw: i guess go's json does too.
-		tab := &enc.Tabs
 		tab.Softline()
 		tab.pad()
 	}
-	return
+	return enc.flush(err)
 }
 
-// fix? determine quote style based on some sort of heuristic....
-func (enc *Encoder) encodeQuotes(str string) {
-	if !strings.ContainsRune(str, runes.Newline) {
-		enc.Tabs.Quote(str)
-	} else {
-		// note: using strings.FieldsFunc isnt enough
-		// by creating left and right parts; it eats trailing newlines
-		var lines []string
-		var prev int
-		for i, q := range str {
-			if q == runes.Newline {
-				lines = append(lines, str[prev:i])
-				prev = i + 1
+// does the passed value encode as a mapping or sequence?
+func (enc *Encoder) isCollection(v r.Value) (okay bool) {
+	if v.IsValid() {
+		if t := v.Type(); enc.Formatters[t] != nil || t == rawType {
+			okay = false // raw values include their own comments
+		} else if t.Implements(mappingType) || t.Implements(sequenceType) {
+			okay = true
+		} else {
+			switch v.Kind() {
+			case r.Pointer, r.Interface:
+				okay = enc.isCollection(v.Elem())
+			case r.Array, r.Slice, r.Map:
+				okay = true
+			case r.Func:
+				_, e := iteratorType(t)
+				okay = e == nil
+			case r.Chan:
+				okay = t.ChanDir()&r.RecvDir != 0
 			}
 		}
-		lines = append(lines, str[prev:])
-		writeHere(&enc.Tabs, lines)
-	}
-}
-
-func writeHere(tab *TabWriter, lines []string) {
-	if len(lines) == 0 {
-		panic("heredocs should have lines")
-	}
-	tab.WriteString(`|`)
-	tab.Indent(true)
-	var escaped, emptyLine bool
-	for _, el := range lines {
-		tab.Nextline()
-		if tab.Escape(el) {
-			escaped = true
-		}
-		emptyLine = len(el) == 0
-	}
-	if !emptyLine {
-		// there was content in the final line,
-		// so the heredoc should trim the final line.
-		// if we're escaping, we have to do that with backslash.
-		if escaped {
-			tab.WriteRune('\\')
-		}
-		tab.Nextline()
 	}
-	// if we're escaping we have to write the double quotes
-	// if we're not escaping we can choose to write it if the final line was empty
-	if escaped || emptyLine {
-		tab.WriteString(`"""`)
-	} else {
-		tab.WriteString(`'''`)
-	}
-	tab.Indent(false)
+	return
 }
 
 // writes a single value to the stream wrapped by tab writer
 // if the parent was  map, and there is a new sequence;
 // then we want a newline
-func (enc *Encoder) WriteValue(v r.Value, wasMaps bool) (err error) {
+// flushes the tab writer when done.
+func (enc *Encoder) WriteValue(v r.Value, wasMaps bool) error {
+	return enc.flush(enc.writeValue(v, wasMaps, enc.QuoteStyle))
+}
+
+// write whatever was buffered, even on error.
+// returns the passed error, or the error from writing.
+func (enc *Encoder) flush(err error) error {
+	if e := enc.Tabs.Flush(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+func (enc *Encoder) writeValue(v r.Value, wasMaps bool, style QuoteStyle) (err error) {
 	// skips nil values; hrm.
 	if v.IsValid() {
 		tab := &enc.Tabs
+		if t := v.Type(); t.Implements(keyCommentsType) && v.CanInterface() && enc.isCollection(v) {
+			enc.pending = tellKeyComments(v.Interface().(TellKeyComments))
+		} else if t.Implements(commentsType) && v.CanInterface() && enc.isCollection(v) {
+			enc.pending = tellComments(v.Interface().(TellComments))
+		}
 
-		if t := v.Type(); t.Implements(mappingType) {
+		if t := v.Type(); enc.Formatters[t] != nil {
+			if str, e := enc.Formatters[t](v); e != nil {
+				err = e
+			} else {
+				tab.WriteString(str)
+			}
+		} else if t.Implements(mappingType) {
 			m := v.Interface().(TellMapping)
-			err = enc.WriteMapping(m.TellMapping(), wasMaps)
+			err = enc.writeMapping(m.TellMapping(), wasMaps)
 
 		} else if t.Implements(sequenceType) {
 			m := v.Interface().(TellSequence)
-			err = enc.WriteSequence(m.TellSequence(), wasMaps)
+			err = enc.writeSequence(m.TellSequence(), wasMaps)
+		} else if t == rawType {
+			enc.writeRaw(v.Bytes(), wasMaps)
+		} else if t == heredocType {
+			err = writeDoc(tab, v.Interface().(charmed.Heredoc))
+		} else if t == numberType {
+			// numbers decoded as text get written as they were originally spelled.
+			err = enc.writeNumber(charmed.Number(v.String()))
 		} else {
 			switch k := v.Kind(); k {
 			case r.Pointer, r.Interface:
-				err = enc.WriteValue(v.Elem(), wasMaps)
+				err = enc.writeValue(v.Elem(), wasMaps, style)
 
 			case r.Bool:
-				str := formatBool(v)
-				tab.WriteString(str)
+				tab.writeValue(v, appendBool)
 
 			case r.Int, r.Int8, r.Int16, r.Int32, r.Int64:
-				str := formatInt(v)
-				tab.WriteString(str)
+				tab.writeValue(v, appendInt)
 
 			case r.Uint, r.Uint8, r.Uint16, r.Uint32, r.Uint64:
 				// tbd: tag for format? ( hex, #, etc. )
-				str := formatUint(v)
-				tab.WriteString(str)
+				tab.writeValue(v, appendUint)
 
 			case r.Float32, r.Float64:
-				str := formatFloat(v)
-				if f := v.Float(); math.IsInf(f, 0) || math.IsNaN(f) {
-					err = fmt.Errorf("unsupported value %s", str)
+				if f := v.Float(); !math.IsInf(f, 0) && !math.IsNaN(f) {
+					tab.writeValue(v, appendFloat)
+				} else if !enc.UseInfNaN {
+					err = fmt.Errorf("unsupported value %s", appendFloat(nil, v))
 				} else {
-					tab.WriteString(str)
+					tab.writeValue(v, appendInfNaN)
 				}
 
 			case r.String:
-				enc.encodeQuotes(v.String())
+				writeQuotes(tab, v.String(), style)
 
 			case r.Array, r.Slice:
 				// tbd: look at tag for "want array"?
@@ -158,27 +215,54 @@ func (enc *Encoder) WriteValue(v r.Value, wasMaps bool) (err error) {
 					tab.WriteRune(runes.ArrayOpen)
 					tab.WriteRune(runes.ArrayClose)
 				} else {
-					err = enc.WriteSequence(it, wasMaps)
+					err = enc.writeSequence(it, wasMaps)
 				}
 
 			case r.Map:
 				if it, e := enc.Mapper(v); e != nil {
 					err = e
 				} else if it != nil {
-					err = enc.WriteMapping(it, wasMaps)
+					err = enc.writeMapping(it, wasMaps)
+				}
+
+			case r.Func:
+				if !v.IsNil() {
+					err = enc.writeFunc(v, wasMaps)
+				}
+
+			case r.Chan:
+				if !v.IsNil() {
+					err = enc.writeChan(v, wasMaps)
 				}
 
 			default:
-				// others: Complex, Chan, Func, UnsafePointer
+				// others: Complex, UnsafePointer
 				err = fmt.Errorf("unexpected type %s %s", v.Kind(), v.Type())
 			}
 		}
+		enc.pending = nil // in case the collection had no elements
+	}
+	return
+}
+
+func (enc *Encoder) writeNumber(n charmed.Number) (err error) {
+	if e := n.Validate(); e != nil {
+		err = e
+	} else if str := n.String(); !enc.UseInfNaN && (strings.HasSuffix(str, "inf") || str == "nan") {
+		err = fmt.Errorf("unsupported value %s", str)
+	} else {
+		enc.Tabs.WriteString(str)
 	}
 	return
 }
 
+var numberType = r.TypeOf(charmed.Number(""))
+var heredocType = r.TypeOf(charmed.Heredoc{})
+var rawType = r.TypeOf(charmed.RawValue(nil))
 var mappingType = r.TypeOf((*TellMapping)(nil)).Elem()
 var sequenceType = r.TypeOf((*TellSequence)(nil)).Elem()
+var commentsType = r.TypeOf((*TellComments)(nil)).Elem()
+var keyCommentsType = r.TypeOf((*TellKeyComments)(nil)).Elem()
 
 // get the value of an iterator, ducking down to GetReflectedValue if it exists
 func getValue(v interface{ GetValue() any }) (ret r.Value) {
@@ -191,92 +275,178 @@ func getValue(v interface{ GetValue() any }) (ret r.Value) {
 	return
 }
 
-func (enc *Encoder) WriteMapping(it Iterator, wasMaps bool) (err error) {
+// writes the elements of a mapping to the stream wrapped by the tab writer.
+// flushes the tab writer when done.
+func (enc *Encoder) WriteMapping(it Iterator, wasMaps bool) error {
+	return enc.flush(enc.writeMapping(it, wasMaps))
+}
+
+// writes the elements of a sequence to the stream wrapped by the tab writer.
+// flushes the tab writer when done.
+func (enc *Encoder) WriteSequence(it Iterator, wasMaps bool) error {
+	return enc.flush(enc.writeSequence(it, wasMaps))
+}
+
+func (enc *Encoder) writeMapping(it Iterator, wasMaps bool) error {
 	return enc.writeCollection(it, enc.MapComments, wasMaps, true)
 }
 
-func (enc *Encoder) WriteSequence(it Iterator, wasMaps bool) (err error) {
+func (enc *Encoder) writeSequence(it Iterator, wasMaps bool) error {
 	return enc.writeCollection(it, enc.SequenceComments, wasMaps, false)
 }
 
 func (enc *Encoder) writeCollection(it Iterator, cmts Commenting, wasMaps, maps bool) (err error) {
-	tab := &enc.Tabs
-	hasNext := it.Next() // dance around the possibly blank first element
-	if !hasNext {
-		return
-	}
-
-	// setup a comment iterator:
-	var cit Comments = noComments{} // expect none by default
-	if cmts != nil {
+	w := collectionWriter{enc: enc, cmts: cmts, wasMaps: wasMaps, maps: maps}
+	for err == nil && it.Next() {
 		key, val := it.GetKey(), getValue(it)
-		if !maps || len(key) == 0 {
-			cit, err = cmts(val)
-			hasNext = err == nil && it.Next() // skip this comment value.
+		style := enc.QuoteStyle
+		if qs, ok := it.(GetQuoteStyle); ok {
+			if s, ok := qs.GetQuoteStyle(); ok {
+				style = s
+			}
 		}
+		err = w.element(key, val, style)
 	}
-	if !hasNext && err == nil {
-		if cit.Next() {
-			cmt := cit.GetComment()
-			tab.writeInline(cmt.Header)
-		}
-		if !maps {
-			// this probably needs to be more sophisticated.
-			// for prefix/suffix comments
-			tab.WriteRune(runes.ArrayOpen)
-			tab.WriteRune(runes.ArrayClose)
-			tab.Softline()
-		}
-		return // early out.
+	if err == nil {
+		err = w.end()
 	}
-	if wasMaps {
-		tab.Softline()
+	return
+}
+
+// writes the elements of a collection as they're handed to it;
+// so that iterators can either be pulled from, or push their elements.
+type collectionWriter struct {
+	enc           *Encoder
+	cmts          Commenting
+	cit           Comments
+	wasMaps, maps bool
+	started       bool // the first element ( if any ) was seen
+	commented     bool // the collection has comments, even if it has no elements
+	idx           int  // the number of elements written
+}
+
+// setup the comment iterator;
+// looks at the first element ( if there is one ) because it might hold the comments.
+// returns true if it did.
+func (w *collectionWriter) start(first bool, key string, val r.Value) (skip bool, err error) {
+	enc := w.enc
+	w.started = true
+	if pending := enc.pending; pending != nil {
+		enc.pending = nil
+		w.cit, w.commented = pending, true // the collection provided its own comments
+	} else if str := enc.Sidecar[enc.path]; w.cmts == nil && len(str) > 0 {
+		w.cit, w.commented = &commentBlock{rest: str}, true
+	} else if first && w.cmts != nil && (!w.maps || len(key) == 0) {
+		w.cit, err = w.cmts(val)
+		w.commented, skip = true, true // skip this comment value.
 	}
-	//
-	for hasNext {
-		key, val := it.GetKey(), getValue(it)
-		if len(key) == 0 {
-			err = errors.New("can't encode empty keys; maybe you meant to encode with comments?")
-			break
+	if w.cit == nil {
+		w.cit = noComments{} // expect none by default
+	}
+	return
+}
+
+// write the next element of the collection
+func (w *collectionWriter) element(key string, val r.Value, style QuoteStyle) (err error) {
+	var skip bool
+	if !w.started {
+		skip, err = w.start(true, key, val)
+	}
+	if err == nil && !skip {
+		err = w.write(key, val, style)
+	}
+	return
+}
+
+func (w *collectionWriter) write(key string, val r.Value, style QuoteStyle) (err error) {
+	enc, tab := w.enc, &w.enc.Tabs
+	if len(key) == 0 {
+		err = errors.New("can't encode empty keys; maybe you meant to encode with comments?")
+	} else {
+		if w.idx == 0 && w.wasMaps {
+			tab.Softline()
 		}
-		hasNext = it.Next()
 		var cmt Comment
-		if cit.Next() {
-			cmt = cit.GetComment()
+		if k, ok := w.cit.(*keyComments); ok {
+			cmt = k.forKey(key)
+		} else if w.cit.Next() {
+			cmt = w.cit.GetComment()
 		}
 		// header comment:
 		tab.writeLines(cmt.Header)
 		// key; friendliness; write a separating colon if needed.
 		tab.WriteString(key)
-		if maps && key[len(key)-1] != runes.Colon {
+		if w.maps && key[len(key)-1] != runes.Colon {
 			tab.WriteRune(runes.Colon)
 		}
+		parent := enc.path
+		if enc.Sidecar != nil {
+			enc.path = elementPath(parent, key, w.idx, w.maps)
+		}
+		w.idx++
 		tab.Indent(true)
-		{
-			// prefix comment:
-			if prefix := cmt.Prefix; len(prefix) == 0 {
-				tab.Space()
-			} else {
-				fixedWrite(tab, prefix)
-			}
-			// value: recursive!
-			if e := enc.WriteValue(val, maps); e != nil {
-				err = e
-				break
-			}
-			if suffix := cmt.Suffix; len(suffix) > 0 {
-				fixedWrite(tab, suffix)
-			}
+		// prefix comment:
+		if prefix := cmt.Prefix; len(prefix) == 0 {
+			tab.Space()
+		} else {
+			fixedWrite(tab, prefix)
+		}
+		// value: recursive!
+		if e := enc.writeValue(val, w.maps, style); e != nil {
+			err = e
+		} else if e := tab.Err(); e != nil {
+			err = e // stop once the output fails
+		} else if suffix := cmt.Suffix; len(suffix) > 0 {
+			fixedWrite(tab, suffix)
 		}
 		tab.Indent(false)
 		tab.Softline()
+		enc.path = parent
 	}
+	return
+}
 
-	// write the footer ( if any )
-	// ( it appears as a header comment for a non existent item )
-	if err == nil && cit.Next() {
-		cmt := cit.GetComment()
-		tab.writeLines(cmt.Header)
+// finish the collection after its last element.
+func (w *collectionWriter) end() (err error) {
+	tab := &w.enc.Tabs
+	if !w.started {
+		_, err = w.start(false, "", r.Value{})
+	}
+	if err != nil {
+		// the comments were rejected
+	} else if w.idx > 0 {
+		// write the footer ( if any )
+		// ( it appears as a header comment for a non existent item )
+		if w.cit.Next() {
+			cmt := w.cit.GetComment()
+			tab.writeLines(cmt.Header)
+		}
+	} else if w.commented {
+		// no elements, but comments:
+		if w.cit.Next() {
+			cmt := w.cit.GetComment()
+			tab.writeInline(cmt.Header)
+		}
+		if !w.maps {
+			// this probably needs to be more sophisticated.
+			// for prefix/suffix comments
+			tab.WriteRune(runes.ArrayOpen)
+			tab.WriteRune(runes.ArrayClose)
+			tab.Softline()
+		}
+	}
+	return
+}
+
+// the path of an element within a collection
+// ( the key of a mapping always includes its colon. )
+func elementPath(parent collect.Path, key string, idx int, maps bool) (ret collect.Path) {
+	if !maps {
+		ret = parent.Index(idx)
+	} else if key[len(key)-1] != runes.Colon {
+		ret = parent.Key(key + string(runes.Colon))
+	} else {
+		ret = parent.Key(key)
 	}
 	return
 }
@@ -16,6 +16,24 @@ type TellSequence interface {
 	TellSequence() Iterator
 }
 
+// provides comments when implemented by a value that's being encoded as a sequence
+// ( ex. a native slice, or an implementation of TellSequence )
+// each comment belongs to one element in the order those elements are written;
+// an extra comment at the end becomes the footer of the sequence.
+// lines without a leading hash are written as comments: the hash is added automatically.
+type TellComments interface {
+	TellComments() []Comment
+}
+
+// provides comments when implemented by a value that's being encoded as a mapping
+// ( ex. a native map, or an implementation of TellMapping )
+// each comment belongs to the element with the same key, regardless of the order keys are written;
+// the comment for the empty key becomes the footer of the mapping.
+// lines without a leading hash are written as comments: the hash is added automatically.
+type TellKeyComments interface {
+	TellKeyComments() map[string]Comment
+}
+
 // the key for sequences
 const Dashing = "-"
 
@@ -35,6 +53,13 @@ type GetReflectedValue interface {
 	GetReflectedValue() r.Value
 }
 
+// if implemented by one of the iterators
+// overrides the encoder's quote style for the current element.
+// returns false to use the encoder's style.
+type GetQuoteStyle interface {
+	GetQuoteStyle() (QuoteStyle, bool)
+}
+
 type Comment struct {
 	Header []string // before the key
 	Prefix []string // the key comment, between the key and value
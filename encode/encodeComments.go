@@ -53,6 +53,44 @@ func ExtractString(el r.Value) (ret string, err error) {
 	return
 }
 
+// uses the comments from a TellComments implementation
+func tellComments(v TellComments) Comments {
+	els := v.TellComments()
+	out := make([]Comment, len(els))
+	for i, el := range els {
+		out[i] = hashComment(el)
+	}
+	return CommentSlice(out)
+}
+
+// uses the comments from a TellKeyComments implementation
+func tellKeyComments(v TellKeyComments) Comments {
+	return &keyComments{els: v.TellKeyComments()}
+}
+
+func hashComment(el Comment) Comment {
+	return Comment{
+		Header: hashLines(el.Header),
+		Prefix: hashLines(el.Prefix),
+		Suffix: hashLines(el.Suffix),
+	}
+}
+
+// ensure each line of a comment starts with a hash
+// ( blank lines are left as is. )
+func hashLines(lines []string) (ret []string) {
+	if len(lines) > 0 {
+		ret = make([]string, len(lines))
+		for i, el := range lines {
+			if len(el) > 0 && el != blankLine && el[0] != runes.Hash {
+				el = "# " + el
+			}
+			ret[i] = el
+		}
+	}
+	return
+}
+
 // forever. nothing.
 type noComments struct{}
 
@@ -78,6 +116,30 @@ func (s *commentSlice) GetComment() Comment {
 	return s.curr
 }
 
+// comments looked up by the key of each element;
+// iterating yields only the footer.
+type keyComments struct {
+	els    map[string]Comment
+	footer bool
+}
+
+func (k *keyComments) Next() (okay bool) {
+	if !k.footer {
+		k.footer = true
+		_, okay = k.els[""]
+	}
+	return
+}
+
+func (k *keyComments) GetComment() Comment {
+	return hashComment(k.els[""])
+}
+
+// the comment for the element with the passed key
+func (k *keyComments) forKey(key string) Comment {
+	return hashComment(k.els[key])
+}
+
 // walk a comment block string
 type commentBlock struct {
 	curr, rest string
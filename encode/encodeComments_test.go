@@ -43,3 +43,56 @@ func TestCommentEncoding(t *testing.T) {
 		t.Fatal(e)
 	}
 }
+
+// a native map which provides its own comments
+type commentedConfig map[string]any
+
+func (commentedConfig) TellKeyComments() map[string]encode.Comment {
+	return map[string]encode.Comment{
+		"start:": {
+			Header: []string{"# starting position", "# x, y"},
+		},
+		"name:": {
+			Header: []string{"the name of the player"},
+			Suffix: []string{"( required )"},
+		},
+		"": {
+			Header: []string{"end of config"},
+		},
+	}
+}
+
+// a native slice which provides its own comments
+type commentedList []int
+
+func (commentedList) TellComments() []encode.Comment {
+	return []encode.Comment{{
+		Suffix: []string{"x"},
+	}, {
+		Suffix: []string{"y"},
+	}}
+}
+
+func TestTellComments(t *testing.T) {
+	src := map[string]any{
+		"config": commentedConfig{
+			"name:":  "player",
+			"start:": commentedList{1, 2},
+		},
+	}
+	var buf strings.Builder
+	enc := encode.MakeEncoder(&buf)
+	if e := enc.Encode(src); e != nil {
+		t.Fatal(e)
+	} else if have, want := buf.String(), "config:\n"+
+		"  # the name of the player\n"+
+		"  name: \"player\" # ( required )\n"+
+		"  # starting position\n"+
+		"  # x, y\n"+
+		"  start:\n"+
+		"    - 1 # x\n"+
+		"    - 2 # y\n"+
+		"  # end of config\n"; have != want {
+		t.Fatalf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
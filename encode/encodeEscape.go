@@ -0,0 +1,81 @@
+package encode
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/ionous/tell/runes"
+)
+
+// append the passed string to the buffer,
+// escaping whatever can't appear directly inside a double quoted string.
+// returns true if there were any escapes.
+//
+// the escapes match those of strconv.Quote, except that malformed utf8
+// is written as the unicode replacement character: the tell decoder
+// reads \x as a rune ( not a byte ), so that's what it would become anyway.
+func appendEscaped(b []byte, s string) (ret []byte, escaped bool) {
+	var start int // start of the current run of unescaped text
+	for i := 0; i < len(s); {
+		q, size := rune(s[i]), 1
+		if q >= utf8.RuneSelf {
+			q, size = utf8.DecodeRuneInString(s[i:])
+		}
+		if !needsEscape(q, size) {
+			i += size
+		} else {
+			b = appendEscapedRune(append(b, s[start:i]...), q)
+			i += size
+			start, escaped = i, true
+		}
+	}
+	ret = append(b, s[start:]...)
+	return
+}
+
+func needsEscape(q rune, size int) (ret bool) {
+	if q < utf8.RuneSelf {
+		ret = q < runes.Space || q == utf8.RuneSelf-1 || q == runes.QuoteDouble || q == runes.Escape
+	} else {
+		ret = (q == utf8.RuneError && size == 1) || !strconv.IsPrint(q)
+	}
+	return
+}
+
+func appendEscapedRune(b []byte, q rune) []byte {
+	b = append(b, runes.Escape)
+	if c, ok := shortEscapes[q]; ok {
+		b = append(b, c)
+	} else if q < utf8.RuneSelf {
+		b = append(b, 'x', lowerhex[q>>4], lowerhex[q&0xf])
+	} else if q < 0x10000 {
+		b = append(b, 'u')
+		b = appendHex(b, q, 4)
+	} else {
+		b = append(b, 'U')
+		b = appendHex(b, q, 8)
+	}
+	return b
+}
+
+func appendHex(b []byte, q rune, width int) []byte {
+	for s := (width - 1) * 4; s >= 0; s -= 4 {
+		b = append(b, lowerhex[(q>>uint(s))&0xf])
+	}
+	return b
+}
+
+const lowerhex = "0123456789abcdef"
+
+// the reverse of charmed's standard escapes
+var shortEscapes = map[rune]byte{
+	'\a': 'a',
+	'\b': 'b',
+	'\f': 'f',
+	'\n': 'n',
+	'\r': 'r',
+	'\t': 't',
+	'\v': 'v',
+	'\\': '\\',
+	'"':  '"',
+}
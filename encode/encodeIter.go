@@ -0,0 +1,126 @@
+package encode
+
+import (
+	"fmt"
+	r "reflect"
+
+	"github.com/ionous/tell/runes"
+)
+
+// range over func iterators:
+// func(yield func(V) bool) writes a sequence,
+// func(yield func(K, V) bool) writes a mapping ( the keys must be strings. )
+// returns an error for any other kind of function.
+// the iterator is called directly: each yield writes an element,
+// and returns false once writing has failed.
+func (enc *Encoder) writeFunc(v r.Value, wasMaps bool) (err error) {
+	if pairs, e := iteratorType(v.Type()); e != nil {
+		err = e
+	} else {
+		cmts := enc.SequenceComments
+		if pairs {
+			cmts = enc.MapComments
+		}
+		w := collectionWriter{enc: enc, cmts: cmts, wasMaps: wasMaps, maps: pairs}
+		var yielded bool
+		yt := v.Type().In(0)
+		yes := r.ValueOf(true).Convert(yt.Out(0))
+		no := r.ValueOf(false).Convert(yt.Out(0))
+		yield := r.MakeFunc(yt, func(args []r.Value) (ret []r.Value) {
+			if err == nil {
+				key := Dashing
+				if pairs {
+					key = args[0].String()
+				}
+				yielded = true
+				err = w.element(key, args[len(args)-1], enc.QuoteStyle)
+			}
+			if err != nil {
+				ret = []r.Value{no}
+			} else {
+				ret = []r.Value{yes}
+			}
+			return
+		})
+		v.Call([]r.Value{yield})
+		if err != nil {
+			// writing failed
+		} else if !yielded {
+			enc.writeEmpty(pairs)
+		} else {
+			err = w.end()
+		}
+	}
+	return
+}
+
+// receive channels write a sequence
+// containing each value received until the channel is closed.
+func (enc *Encoder) writeChan(v r.Value, wasMaps bool) (err error) {
+	if t := v.Type(); t.ChanDir()&r.RecvDir == 0 {
+		err = fmt.Errorf("can't receive from %s", t)
+	} else {
+		it := &chanIter{ch: v}
+		if !it.Next() {
+			enc.writeEmpty(false)
+		} else {
+			it.peeked = true
+			err = enc.writeSequence(it, wasMaps)
+		}
+	}
+	return
+}
+
+// an iterator without any elements writes the same thing as an empty slice or map.
+// ( writing a collection without elements would skip the brackets. )
+func (enc *Encoder) writeEmpty(maps bool) {
+	if !maps {
+		tab := &enc.Tabs
+		tab.WriteRune(runes.ArrayOpen)
+		tab.WriteRune(runes.ArrayClose)
+	}
+}
+
+// does the passed type look like a sequence or mapping iterator?
+// returns true for mappings, false for sequences.
+func iteratorType(t r.Type) (pairs bool, err error) {
+	if t.NumIn() != 1 || t.NumOut() != 0 {
+		err = fmt.Errorf("unexpected type %s %s", t.Kind(), t)
+	} else if y := t.In(0); y.Kind() != r.Func ||
+		y.NumOut() != 1 || y.Out(0).Kind() != r.Bool ||
+		(y.NumIn() != 1 && y.NumIn() != 2) {
+		err = fmt.Errorf("unexpected type %s %s", t.Kind(), t)
+	} else if pairs = y.NumIn() == 2; pairs && y.In(0).Kind() != r.String {
+		err = fmt.Errorf("iterator keys must be string, have %s", y.In(0))
+	}
+	return
+}
+
+// receives values from a channel, one at a time.
+type chanIter struct {
+	ch     r.Value
+	curr   r.Value
+	peeked bool // curr holds the first element, already returned by Next
+}
+
+// always returns "-" for sequences
+func (it *chanIter) GetKey() string {
+	return Dashing
+}
+
+func (it *chanIter) Next() (okay bool) {
+	if it.peeked {
+		it.peeked, okay = false, true
+	} else {
+		it.curr, okay = it.ch.Recv()
+	}
+	return
+}
+
+func (it *chanIter) GetValue() any {
+	return it.curr.Interface()
+}
+
+func (it *chanIter) GetReflectedValue() r.Value {
+	return it.curr
+}
@@ -0,0 +1,88 @@
+package encode_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell/encode"
+)
+
+// iterators and channels write their elements in the order they produce them.
+func TestIterators(t *testing.T) {
+	seq := func(yield func(int) bool) {
+		for i := 1; i <= 3; i++ {
+			if !yield(i) {
+				break
+			}
+		}
+	}
+	pairs := func(yield func(string, any) bool) {
+		_ = yield("z", seq) && yield("a", "b") && yield("m", nil)
+	}
+	ch := make(chan string, 2)
+	ch <- "hello"
+	ch <- "there"
+	close(ch)
+	empty := func(yield func(bool) bool) {}
+	testEncoding(t,
+		/* 0 */ seq, chomp(`
+- 1
+- 2
+- 3`),
+		/* 1 */ pairs, chomp(`
+z:
+  - 1
+  - 2
+  - 3
+a: "b"
+m:`),
+		/* 2 */ (<-chan string)(ch), chomp(`
+- "hello"
+- "there"`),
+		/* 3 */ empty, line(`[]`),
+		/* 4 */ []any{(func(func(int) bool))(nil)}, line(`-`),
+	)
+}
+
+// the iterator should stop once the writer fails
+func TestIteratorStops(t *testing.T) {
+	var calls int
+	seq := func(yield func(string) bool) {
+		for calls = 1; yield("some text"); calls++ {
+		}
+	}
+	w := failingWriter{limit: 2}
+	enc := encode.MakeEncoder(&w)
+	if e := enc.Encode(seq); e == nil {
+		t.Fatal("expected a write error")
+	} else if calls < 2 {
+		t.Fatal("expected several calls, have", calls)
+	}
+}
+
+func TestIteratorErrors(t *testing.T) {
+	var buf strings.Builder
+	enc := encode.MakeEncoder(&buf)
+	for i, v := range []any{
+		func() {},
+		func(int) {},
+		func(yield func(int, int) bool) {},
+		make(chan<- int),
+	} {
+		if e := enc.Encode(v); e == nil {
+			t.Errorf("test %d expected an error", i)
+		}
+	}
+	// panics from the iterator pass through the encoder
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Error("expected the iterator's panic; have", r)
+			}
+		}()
+		enc.Encode(func(yield func(int) bool) {
+			yield(1)
+			panic("boom")
+		})
+	}()
+}
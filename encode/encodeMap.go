@@ -11,6 +11,7 @@ import (
 type MapTransform struct {
 	keyLess      func(a, b string) bool
 	keyTransform func(r.Value) string
+	quoteStyle   func(key string) (QuoteStyle, bool)
 }
 
 // return a factory function for the encoder
@@ -31,6 +32,13 @@ func (m *MapTransform) KeyTransform(t func(keys r.Value) string) *MapTransform {
 	return m
 }
 
+// choose how to quote the strings of particular keys
+// return false to use the encoder's style.
+func (m *MapTransform) QuoteStyle(t func(key string) (QuoteStyle, bool)) *MapTransform {
+	m.quoteStyle = t
+	return m
+}
+
 // fix: change to support error?
 func keyTransform(v r.Value) (ret string) {
 	if k := v.Kind(); k != r.String {
@@ -53,23 +61,32 @@ func (m *MapTransform) makeMapping(src r.Value) (Iterator, error) {
 	}
 
 	var mk mapKeys
-	if keys := src.MapKeys(); len(keys) > 0 {
-		// ugly, but simple:
-		str := make([]string, len(keys))
-		for i, k := range keys {
-			str[i] = xform(k)
+	var vals r.Value
+	if cnt := src.Len(); cnt > 0 {
+		// copy the keys and values into slices all at once
+		// rather than allocating each one separately.
+		t := src.Type()
+		keys := r.MakeSlice(r.SliceOf(t.Key()), cnt, cnt)
+		vals = r.MakeSlice(r.SliceOf(t.Elem()), cnt, cnt)
+		str, idx := make([]string, cnt), make([]int, cnt)
+		for i, it := 0, src.MapRange(); it.Next(); i++ {
+			k := keys.Index(i)
+			k.SetIterKey(it)
+			vals.Index(i).SetIterValue(it)
+			str[i], idx[i] = xform(k), i
 		}
-		mk = mapKeys{str: str, val: keys, keyLess: keyLess}
+		mk = mapKeys{str: str, idx: idx, keyLess: keyLess}
 		sort.Sort(&mk)
 	}
 	//
-	return &mapIter{src: src, mapKeys: mk}, nil
+	return &mapIter{vals: vals, mapKeys: mk, quoteStyle: m.quoteStyle}, nil
 }
 
 type mapIter struct {
-	src     r.Value // the native map
-	mapKeys mapKeys
-	next    int
+	vals       r.Value // a slice of the native map's values
+	mapKeys    mapKeys
+	next       int
+	quoteStyle func(key string) (QuoteStyle, bool)
 }
 
 func (m *mapIter) Next() (okay bool) {
@@ -79,10 +96,6 @@ func (m *mapIter) Next() (okay bool) {
 	return
 }
 
-func (m *mapIter) getKey() r.Value {
-	return m.mapKeys.val[m.next-1]
-}
-
 func (m *mapIter) GetKey() string {
 	return m.mapKeys.str[m.next-1]
 }
@@ -92,6 +105,13 @@ func (m *mapIter) GetValue() any {
 }
 
 func (m *mapIter) GetReflectedValue() r.Value {
-	key := m.getKey()
-	return m.src.MapIndex(key)
+	i := m.mapKeys.idx[m.next-1]
+	return m.vals.Index(i)
+}
+
+func (m *mapIter) GetQuoteStyle() (ret QuoteStyle, okay bool) {
+	if m.quoteStyle != nil {
+		ret, okay = m.quoteStyle(m.GetKey())
+	}
+	return
 }
@@ -1,34 +1,61 @@
 package encode
 
 import (
+	"math"
 	r "reflect"
 	"strconv"
 )
 
-func formatBool(v r.Value) string {
-	return strconv.FormatBool(v.Bool())
+// formats a value by appending its text to the passed buffer.
+type appendValue func(b []byte, v r.Value) []byte
+
+// write a value directly to the output.
+func (tab *TabWriter) writeValue(v r.Value, fn appendValue) (int, error) {
+	tab.pad()
+	start := len(tab.buf)
+	tab.buf = fn(tab.buf, v)
+	cnt := len(tab.buf) - start
+	tab.xpos += cnt
+	return tab.flushed(cnt)
+}
+
+func appendBool(b []byte, v r.Value) []byte {
+	return strconv.AppendBool(b, v.Bool())
 }
 
-func formatInt(v r.Value) string {
+func appendInt(b []byte, v r.Value) []byte {
 	ofs := v.Kind() - intKind
 	width := intBase[ofs]
 	prefix := intPrefix[ofs]
-	return prefix + strconv.FormatInt(v.Int(), width)
+	return strconv.AppendInt(append(b, prefix...), v.Int(), width)
 }
 
-func formatUint(v r.Value) string {
+func appendUint(b []byte, v r.Value) []byte {
 	ofs := v.Kind() - uintKind
 	width := intBase[ofs]
 	prefix := intPrefix[ofs]
-	return prefix + strconv.FormatUint(v.Uint(), width)
+	return strconv.AppendUint(append(b, prefix...), v.Uint(), width)
 }
 
-func formatFloat(v r.Value) string {
+func appendFloat(b []byte, v r.Value) []byte {
 	width := floatWidth[v.Kind()-floatKind]
-	return strconv.FormatFloat(v.Float(), 'g', -1, width)
+	return strconv.AppendFloat(b, v.Float(), 'g', -1, width)
 	// fix: handle infinity, etc?
 }
 
+// lowercase, to match the decoder.
+func appendInfNaN(b []byte, v r.Value) (ret []byte) {
+	switch f := v.Float(); {
+	case math.IsNaN(f):
+		ret = append(b, "nan"...)
+	case f < 0:
+		ret = append(b, "-inf"...)
+	default:
+		ret = append(b, "inf"...)
+	}
+	return
+}
+
 var intKind = r.Int // Int, Int8,	Int16, Int32,	Int64
 var uintKind = r.Uint
 var intBase = []int{10, 16, 16, 10, 10}
@@ -0,0 +1,241 @@
+package encode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ionous/tell/charmed"
+	"github.com/ionous/tell/runes"
+)
+
+// controls how the encoder writes strings.
+// by default, strings are written with double quotes,
+// and multiline strings use the yaml compatible pipe heredoc.
+// the style applies to the whole encoder ( Encoder.QuoteStyle )
+// or to particular keys of a mapping ( MapTransform.QuoteStyle, or an iterator's GetQuoteStyle. )
+// there's no struct tag for it: the encoder doesn't write structs.
+type QuoteStyle int
+
+const (
+	// use raw strings ( backticks ) for text which doesn't need escaping.
+	PreferRaw QuoteStyle = 1 << iota
+	// use trimmed strings ( single quotes ) for text which doesn't need escaping.
+	// ( if both are set, raw strings win )
+	PreferSingle
+	// write multiline text as heredocs opened with triple quotes rather than the pipe.
+	// the kind of quote depends on the text:
+	// raw (```) for text ending in a newline, trimmed (''') for text without one,
+	// and interpreted (""") for text which needs escaping.
+	TripleQuotes
+)
+
+func (s QuoteStyle) Is(flag QuoteStyle) bool {
+	return s&flag != 0
+}
+
+// the custom closing tag for heredocs containing lines which look like closing quotes.
+const hereTag = "END"
+
+func writeQuotes(tab *TabWriter, str string, style QuoteStyle) {
+	if !strings.ContainsRune(str, runes.Newline) {
+		switch {
+		case style.Is(PreferRaw) && canQuote(str, runes.QuoteRaw):
+			writeQuoted(tab, str, runes.QuoteRaw)
+		case style.Is(PreferSingle) && canQuote(str, runes.QuoteSingle):
+			writeQuoted(tab, str, runes.QuoteSingle)
+		default:
+			tab.Quote(str)
+		}
+	} else {
+		// note: using strings.FieldsFunc isnt enough
+		// by creating left and right parts; it eats trailing newlines
+		var lines []string
+		var prev int
+		for i, q := range str {
+			if q == runes.Newline {
+				lines = append(lines, str[prev:i])
+				prev = i + 1
+			}
+		}
+		lines = append(lines, str[prev:])
+		// pipe heredocs end with any triple quote, so lines which look like one need a custom tag.
+		if style.Is(TripleQuotes) || hasTriple(lines, "```", "'''", `"""`) {
+			writeTriple(tab, lines)
+		} else {
+			writeHere(tab, lines)
+		}
+	}
+}
+
+// true if the passed string can be written using the passed quote without escaping.
+// ( newlines are left to the caller )
+func canQuote(str string, quote rune) (okay bool) {
+	okay = true
+	for i := 0; okay && i < len(str); {
+		q, size := utf8.DecodeRuneInString(str[i:])
+		switch q {
+		case quote:
+			okay = false
+		case runes.Escape, runes.QuoteDouble, runes.Newline:
+			// fine when not escaping
+		default:
+			okay = !needsEscape(q, size)
+		}
+		i += size
+	}
+	return
+}
+
+func writeQuoted(tab *TabWriter, str string, quote rune) {
+	tab.WriteRune(quote)
+	tab.WriteString(str)
+	tab.WriteRune(quote)
+}
+
+// does any line start with any of the passed tags?
+// ( ignoring leading spaces; the closing tag can be indented. )
+func hasTriple(lines []string, tags ...string) (okay bool) {
+	for _, el := range lines {
+		el = strings.TrimLeft(el, " ")
+		for _, tag := range tags {
+			if strings.HasPrefix(el, tag) {
+				okay = true
+				break
+			}
+		}
+	}
+	return
+}
+
+// write a heredoc opened with triple quotes
+func writeTriple(tab *TabWriter, lines []string) {
+	writeHeredoc(tab, lines, 0, "", "")
+}
+
+// write a decoded heredoc using its original header.
+// if the text can't be written with its original quotes ( ex. it needs escaping )
+// the quotes are chosen the same way as for TripleQuotes.
+func writeDoc(tab *TabWriter, doc charmed.Heredoc) (err error) {
+	if !isHeaderWord(doc.Lang) {
+		err = fmt.Errorf("invalid heredoc language %q", doc.Lang)
+	} else if !isHeaderWord(doc.Tag) {
+		err = fmt.Errorf("invalid heredoc tag %q", doc.Tag)
+	} else {
+		lines := strings.Split(doc.Text, "\n")
+		if doc.Style == runes.QuotePipe && len(doc.Lang) == 0 && len(doc.Tag) == 0 &&
+			!hasTriple(lines, "```", "'''", `"""`) {
+			writeHere(tab, lines)
+		} else {
+			writeHeredoc(tab, lines, doc.Style, doc.Lang, doc.Tag)
+		}
+	}
+	return
+}
+
+// heredoc languages and tags are single words of printable characters.
+// ( an empty string is fine; it means there's no language or tag. )
+func isHeaderWord(str string) (okay bool) {
+	okay = true
+	for _, q := range str {
+		if q == runes.Space || q == runes.Redirect || q == runes.Escape || !strconv.IsPrint(q) {
+			okay = false
+			break
+		}
+	}
+	return
+}
+
+// write a heredoc opened with triple quotes of the passed style, with an optional language and closing tag.
+// if the text can't be written using the passed style,
+// picks one based on the text ( as described by TripleQuotes. )
+func writeHeredoc(tab *TabWriter, lines []string, quote rune, lang, tag string) {
+	last := len(lines) - 1
+	emptyLine := len(lines[last]) == 0
+	plain := canQuote(strings.Join(lines, "\n"), runes.Eof)
+	switch {
+	case quote == runes.QuoteDouble:
+	case quote == runes.QuoteSingle && plain:
+	case quote == runes.QuoteRaw && plain && emptyLine:
+	case !plain:
+		quote = runes.QuoteDouble
+	case emptyLine:
+		quote = runes.QuoteRaw // raw heredocs keep the final newline
+	default:
+		quote = runes.QuoteSingle // trimmed heredocs eat the final newline
+	}
+	if emptyLine && quote != runes.QuoteSingle {
+		lines = lines[:last] // the closing tag provides the final newline
+	}
+	triple := strings.Repeat(string(quote), 3)
+	endTag := triple
+	tab.WriteString(triple)
+	if len(lang) > 0 {
+		tab.WriteString(lang)
+	}
+	// interpreted strings escape their quotes; the others need a custom tag
+	if len(tag) > 0 || (quote != runes.QuoteDouble && hasTriple(lines, triple)) {
+		if len(tag) == 0 {
+			tag = hereTag
+		}
+		endTag = tag
+		for i := 1; hasTriple(lines, endTag); i++ {
+			endTag = tag + strconv.Itoa(i)
+		}
+		if len(lang) > 0 {
+			tab.WriteRune(runes.Space)
+		}
+		tab.WriteString("<<<")
+		tab.WriteString(endTag)
+	}
+	tab.Indent(true)
+	for _, el := range lines {
+		tab.Nextline()
+		if quote == runes.QuoteDouble {
+			tab.Escape(el)
+		} else if len(el) > 0 {
+			tab.WriteString(el)
+		}
+	}
+	if quote == runes.QuoteDouble && !emptyLine {
+		tab.WriteRune(runes.Escape) // eat the final newline
+	}
+	tab.Nextline()
+	tab.WriteString(endTag)
+	tab.Indent(false)
+}
+
+// write a yaml compatible heredoc.
+func writeHere(tab *TabWriter, lines []string) {
+	if len(lines) == 0 {
+		panic("heredocs should have lines")
+	}
+	tab.WriteString(`|`)
+	tab.Indent(true)
+	var escaped, emptyLine bool
+	for _, el := range lines {
+		tab.Nextline()
+		if tab.Escape(el) {
+			escaped = true
+		}
+		emptyLine = len(el) == 0
+	}
+	if !emptyLine {
+		// there was content in the final line,
+		// so the heredoc should trim the final line.
+		// if we're escaping, we have to do that with backslash.
+		if escaped {
+			tab.WriteRune('\\')
+		}
+		tab.Nextline()
+	}
+	// if we're escaping we have to write the double quotes
+	// if we're not escaping we can choose to write it if the final line was empty
+	if escaped || emptyLine {
+		tab.WriteString(`"""`)
+	} else {
+		tab.WriteString(`'''`)
+	}
+	tab.Indent(false)
+}
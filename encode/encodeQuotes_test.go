@@ -0,0 +1,107 @@
+package encode_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell"
+	"github.com/ionous/tell/encode"
+)
+
+func TestQuoteStyles(t *testing.T) {
+	tests := []struct {
+		style  encode.QuoteStyle
+		src    string
+		expect string
+	}{
+		{0, `plain`, `"plain"`},
+		{encode.PreferRaw, `back\slash`, "`back\\slash`"},
+		{encode.PreferRaw, "has `ticks`", `"has ` + "`ticks`" + `"`},
+		{encode.PreferRaw, "tab\there", `"tab\there"`},
+		{encode.PreferSingle, `back\slash`, `'back\slash'`},
+		{encode.PreferSingle, `it's`, `"it's"`},
+		{encode.PreferRaw | encode.PreferSingle, "`tick`", "'`tick`'"},
+		// multiline text:
+		{0, "hello\nthere", "|\n  hello\n  there\n  '''"},
+		{encode.TripleQuotes, "hello\nthere\n", "```\n  hello\n  there\n  ```"},
+		{encode.TripleQuotes, "hello\nthere", "'''\n  hello\n  there\n  '''"},
+		{encode.TripleQuotes, "hello\tthere\n", "\"\"\"\n  hello\\tthere\n  \"\"\""},
+		{encode.TripleQuotes, "hello\tthere\nagain", "\"\"\"\n  hello\\tthere\n  again\\\n  \"\"\""},
+		// lines that look like closing quotes:
+		{0, "hello\n'''\n", "```\n  hello\n  '''\n  ```"},
+		{0, "hello\n```\n", "```<<<END\n  hello\n  ```\n  END"},
+		{0, "END\n```\n", "```<<<END1\n  END\n  ```\n  END1"},
+	}
+	for i, test := range tests {
+		var buf strings.Builder
+		enc := encode.MakeEncoder(&buf)
+		enc.QuoteStyle = test.style
+		if e := enc.Encode(test.src); e != nil {
+			t.Errorf("failed test %d: %s", i, e)
+		} else if got := buf.String(); got != test.expect+"\n" {
+			t.Errorf("failed test %d\nhave:\n%s\nwant:\n%s", i, got, test.expect)
+		} else {
+			var res string
+			if e := tell.Unmarshal([]byte(got), &res); e != nil {
+				t.Errorf("failed test %d: couldn't decode %s", i, e)
+			} else if res != test.src {
+				t.Errorf("failed test %d: decoded %q", i, res)
+			}
+		}
+	}
+}
+
+// every style should read back the same text
+func TestQuoteRoundTrip(t *testing.T) {
+	strs := []string{
+		"", "plain", `back\slash`, `"quoted"`, "it's", "`ticks`", "tab\there", "  spaces  ",
+		"\n", "one\n", "one\ntwo", "one\ntwo\n", "one\n\ntwo\n\n", "  indented\nlines",
+		"bell\a\nline", "ends with\\", "ends with\\\n",
+		"\"\"\"\n'''\n```", "END\n'''\n```\n",
+	}
+	styles := []encode.QuoteStyle{
+		0,
+		encode.PreferRaw,
+		encode.PreferSingle,
+		encode.TripleQuotes,
+		encode.PreferRaw | encode.PreferSingle | encode.TripleQuotes,
+	}
+	for _, style := range styles {
+		src := map[string]any{}
+		for i, str := range strs {
+			src[string(rune('a'+i))] = []any{str}
+		}
+		var buf strings.Builder
+		enc := encode.MakeEncoder(&buf)
+		enc.QuoteStyle = style
+		if e := enc.Encode(src); e != nil {
+			t.Fatal(e)
+		}
+		var res map[string]any
+		if e := tell.Unmarshal([]byte(buf.String()), &res); e != nil {
+			t.Fatalf("style %d: %s\n%s", style, e, buf.String())
+		}
+		for i, str := range strs {
+			key := string(rune('a'+i)) + ":"
+			if got := res[key].([]any)[0]; got != str {
+				t.Errorf("style %d: have %q want %q", style, got, str)
+			}
+		}
+	}
+}
+
+// the map transform can change the style of individual keys
+func TestQuoteStyleOverride(t *testing.T) {
+	var m encode.MapTransform
+	m.QuoteStyle(func(key string) (encode.QuoteStyle, bool) {
+		return encode.PreferRaw, key == "raw"
+	})
+	var buf strings.Builder
+	enc := encode.MakeEncoder(&buf)
+	enc.Mapper = m.Mapper()
+	if e := enc.Encode(map[string]any{"raw": `a\b`, "str": `a\b`}); e != nil {
+		t.Fatal(e)
+	} else if got, want := buf.String(), "raw: `a\\b`\nstr: \"a\\\\b\"\n"; got != want {
+		t.Errorf("have:\n%s\nwant:\n%s", got, want)
+	}
+}
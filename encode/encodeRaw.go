@@ -0,0 +1,60 @@
+package encode
+
+import (
+	"bytes"
+
+	"github.com/ionous/tell/charmed"
+	"github.com/ionous/tell/token"
+)
+
+// write the source text of a value as is;
+// re-indenting its lines to fit the current position.
+// ( lines inside of multi-line tokens, ex. raw strings and heredocs, are written without changes. )
+func (enc *Encoder) writeRaw(raw charmed.RawValue, wasMaps bool) {
+	tab := &enc.Tabs
+	layout := scanRaw(raw)
+	if wasMaps && layout.first == token.Key {
+		tab.Softline() // collections written as the value of a key start on the following line.
+	}
+	for i, line := range bytes.Split(raw, []byte{'\n'}) {
+		if i > 0 {
+			if layout.inside[i] {
+				tab.writeUnindented(line) // the token's own text
+				continue
+			}
+			tab.Softline()
+			if len(line) == 0 {
+				tab.Nextline() // the empty line doesn't get indented.
+				continue
+			}
+		}
+		tab.WriteString(string(line))
+	}
+}
+
+// the layout of a raw value, found by scanning its tokens.
+type rawLayout struct {
+	scan   *token.Scanner
+	first  token.Type   // the first token that isn't a comment
+	inside map[int]bool // the lines inside of multi-line tokens
+}
+
+func scanRaw(raw charmed.RawValue) rawLayout {
+	var layout rawLayout
+	layout.scan = token.Tokenizer{Notifier: &layout}.Scanner(raw)
+	layout.scan.Scan() // an invalid value is still written as is
+	return layout
+}
+
+func (n *rawLayout) Decoded(at token.Pos, t token.Type, _ any) (err error) {
+	if n.first == token.Invalid && t != token.Comment {
+		n.first = t
+	}
+	for y := at.Y + 1; y <= n.scan.Pos().Y; y++ {
+		if n.inside == nil {
+			n.inside = make(map[int]bool)
+		}
+		n.inside[y] = true
+	}
+	return
+}
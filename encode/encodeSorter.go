@@ -1,12 +1,8 @@
 package encode
 
-import (
-	r "reflect"
-)
-
 type mapKeys struct {
 	str     []string
-	val     []r.Value
+	idx     []int // index of the original key
 	keyLess func(a, b string) bool
 }
 
@@ -27,5 +23,5 @@ func (m *mapKeys) Less(i, j int) (ret bool) {
 
 func (m *mapKeys) Swap(i, j int) {
 	m.str[i], m.str[j] = m.str[j], m.str[i]
-	m.val[i], m.val[j] = m.val[j], m.val[i]
+	m.idx[i], m.idx[j] = m.idx[j], m.idx[i]
 }
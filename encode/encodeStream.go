@@ -0,0 +1,237 @@
+package encode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	r "reflect"
+
+	"github.com/ionous/tell/charmed"
+	"github.com/ionous/tell/runes"
+)
+
+// writes a document one piece at a time,
+// for generating documents too large to build in memory first.
+// calls which would produce an invalid document
+// ( ex. a value in a mapping without a key, or unbalanced ends ) return an error,
+// and the first error sticks: every call after it returns the same error.
+//
+//	s := encode.MakeStream(w)
+//	s.BeginMapping()
+//	s.Key("Name:")
+//	s.Scalar("tell")
+//	s.End()
+//	err := s.Close()
+type Stream struct {
+	// writes the scalar values;
+	// its settings ( quote style, formatters, etc. ) apply to the stream.
+	Encoder Encoder
+	stack   []streamFrame // the open collections
+	done    bool          // true once the document value has been written
+	err     error
+}
+
+type streamFrame struct {
+	maps    bool // a mapping, otherwise a sequence
+	wasMaps bool // the collection is the value of a key
+	keyed   bool // a key is waiting for its value
+	count   int  // number of values written
+}
+
+func MakeStream(w io.Writer) Stream {
+	return Stream{Encoder: MakeEncoder(w)}
+}
+
+// start a new mapping; end it with End()
+func (s *Stream) BeginMapping() error {
+	return s.begin(true)
+}
+
+// start a new sequence; end it with End()
+func (s *Stream) BeginSequence() error {
+	return s.begin(false)
+}
+
+// write the key for the next value of the current mapping.
+// adds a trailing colon if the key doesn't have one.
+func (s *Stream) Key(key string) (err error) {
+	if s.err != nil {
+		err = s.err
+	} else if len(key) == 0 {
+		err = s.fail(errors.New("can't encode empty keys"))
+	} else if top := s.top(); top == nil || !top.maps {
+		err = s.fail(fmt.Errorf("key %q outside of a mapping", key))
+	} else if top.keyed {
+		err = s.fail(fmt.Errorf("key %q follows a key without a value", key))
+	} else {
+		tab := &s.Encoder.Tabs
+		s.startEntry(top)
+		tab.WriteString(key)
+		if key[len(key)-1] != runes.Colon {
+			tab.WriteRune(runes.Colon)
+		}
+		tab.Indent(true)
+		tab.Space()
+		top.keyed = true
+	}
+	return
+}
+
+// write a bool, number, or string.
+// ( nil writes an empty value; collections need BeginMapping or BeginSequence. )
+func (s *Stream) Scalar(v any) (err error) {
+	val := r.ValueOf(v)
+	if s.err != nil {
+		err = s.err
+	} else if s.Encoder.isCollection(val) {
+		err = s.fail(fmt.Errorf("expected a scalar, have %T", v))
+	} else if e := s.beginValue(); e != nil {
+		err = e
+	} else if e := s.Encoder.writeValue(val, false, s.Encoder.QuoteStyle); e != nil {
+		err = s.fail(e)
+	} else {
+		err = s.endValue()
+	}
+	return
+}
+
+// write a heredoc, including its language and closing tag ( if any )
+func (s *Stream) Heredoc(doc charmed.Heredoc) (err error) {
+	if e := s.beginValue(); e != nil {
+		err = e
+	} else if e := writeDoc(&s.Encoder.Tabs, doc); e != nil {
+		err = s.fail(e)
+	} else {
+		err = s.endValue()
+	}
+	return
+}
+
+// write comment lines on their own lines before the next key or element
+// ( or, at the end of a collection, after its last one. )
+// lines without a leading hash get one.
+func (s *Stream) Comment(lines ...string) (err error) {
+	if s.err != nil {
+		err = s.err
+	} else if top := s.top(); top != nil && top.keyed {
+		err = s.fail(errors.New("comment between a key and its value"))
+	} else {
+		if top != nil {
+			s.startEntry(top)
+		}
+		s.Encoder.Tabs.writeLines(hashLines(lines))
+	}
+	return
+}
+
+// finish the current mapping or sequence.
+func (s *Stream) End() (err error) {
+	if s.err != nil {
+		err = s.err
+	} else if top := s.top(); top == nil {
+		err = s.fail(errors.New("end without a matching begin"))
+	} else if top.keyed {
+		err = s.fail(errors.New("end of mapping after a key without a value"))
+	} else {
+		if !top.maps && top.count == 0 {
+			tab := &s.Encoder.Tabs
+			tab.WriteRune(runes.ArrayOpen)
+			tab.WriteRune(runes.ArrayClose)
+		}
+		s.stack = s.stack[:len(s.stack)-1]
+		err = s.endValue()
+	}
+	return
+}
+
+// verify the document is complete, and write any buffered output.
+func (s *Stream) Close() (err error) {
+	tab := &s.Encoder.Tabs
+	if s.err != nil {
+		err = s.err
+	} else if len(s.stack) > 0 {
+		err = s.fail(fmt.Errorf("%d collection(s) still open", len(s.stack)))
+	} else {
+		// ends with an artificial newline, same as Encode.
+		tab.Softline()
+		tab.pad()
+	}
+	if e := tab.Flush(); e != nil && err == nil {
+		err = s.fail(e)
+	}
+	return
+}
+
+func (s *Stream) begin(maps bool) (err error) {
+	if e := s.beginValue(); e != nil {
+		err = e
+	} else {
+		var wasMaps bool
+		if top := s.top(); top != nil {
+			wasMaps = top.maps
+		}
+		s.stack = append(s.stack, streamFrame{maps: maps, wasMaps: wasMaps})
+	}
+	return
+}
+
+// called before writing any value
+func (s *Stream) beginValue() (err error) {
+	if s.err != nil {
+		err = s.err
+	} else if top := s.top(); top == nil {
+		if s.done {
+			err = s.fail(errors.New("a document has only one value"))
+		}
+	} else if top.maps {
+		if !top.keyed {
+			err = s.fail(errors.New("value in a mapping without a key"))
+		}
+	} else {
+		tab := &s.Encoder.Tabs
+		s.startEntry(top)
+		tab.WriteString(Dashing)
+		tab.Indent(true)
+		tab.Space()
+	}
+	return
+}
+
+// called after writing any value
+func (s *Stream) endValue() (err error) {
+	if top := s.top(); top == nil {
+		s.done = true
+	} else {
+		tab := &s.Encoder.Tabs
+		tab.Indent(false)
+		tab.Softline()
+		top.keyed = false
+		top.count++
+	}
+	// stop once the output fails
+	if e := s.Encoder.Tabs.Err(); e != nil {
+		err = s.fail(e)
+	}
+	return
+}
+
+// a collection which is the value of a key starts on the line after that key.
+// ( waits for the first entry because empty collections stay on the same line. )
+func (s *Stream) startEntry(top *streamFrame) {
+	if top.wasMaps {
+		top.wasMaps = false
+		s.Encoder.Tabs.Softline()
+	}
+}
+
+func (s *Stream) top() (ret *streamFrame) {
+	if n := len(s.stack); n > 0 {
+		ret = &s.stack[n-1]
+	}
+	return
+}
+
+func (s *Stream) fail(e error) error {
+	s.err = e
+	return e
+}
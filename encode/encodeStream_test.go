@@ -0,0 +1,154 @@
+package encode_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell/charmed"
+	"github.com/ionous/tell/encode"
+	"github.com/ionous/tell/runes"
+)
+
+// streaming the same values as TestEncodingMap should write the same document
+func TestStream(t *testing.T) {
+	var buf strings.Builder
+	s := encode.MakeStream(&buf)
+	s.BeginMapping()
+	s.Key("bool")
+	s.Scalar(true)
+	s.Key("empty")
+	s.BeginSequence()
+	s.End()
+	s.Key("hello")
+	s.Scalar("there")
+	s.Key("heredoc")
+	s.Heredoc(charmed.Heredoc{
+		Style: runes.QuotePipe,
+		Text:  lines("a string", "with several lines", "becomes a heredoc."),
+	})
+	s.Key("map")
+	{
+		s.BeginMapping()
+		s.Key("bool")
+		s.Scalar(true)
+		s.Key("hello")
+		s.Scalar("world")
+		s.Key("value")
+		s.Scalar(11)
+		s.End()
+	}
+	s.Key("nil")
+	s.Scalar(nil)
+	s.Key("slice")
+	{
+		s.BeginSequence()
+		s.Scalar("5")
+		s.Scalar(5)
+		s.Scalar(false)
+		s.End()
+	}
+	s.Key("value:")
+	s.Scalar(23)
+	s.End()
+	if e := s.Close(); e != nil {
+		t.Fatal(e)
+	} else if have, want := buf.String(), string(encodedTest); have != want {
+		t.Fatalf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+func TestStreamComments(t *testing.T) {
+	const want = "# header\n" +
+		"- a: 1\n" +
+		"  # about b\n" +
+		"  b:\n" +
+		"    - 2\n" +
+		"    # footer of b\n" +
+		"- []\n" +
+		"# the end\n"
+	var buf strings.Builder
+	s := encode.MakeStream(&buf)
+	s.Comment("header")
+	s.BeginSequence()
+	{
+		s.BeginMapping()
+		s.Key("a")
+		s.Scalar(1)
+		s.Comment("# about b")
+		s.Key("b")
+		s.BeginSequence()
+		s.Scalar(2)
+		s.Comment("footer of b")
+		s.End()
+		s.End()
+	}
+	s.BeginSequence()
+	s.End()
+	s.End()
+	s.Comment("the end")
+	if e := s.Close(); e != nil {
+		t.Fatal(e)
+	} else if have := buf.String(); have != want {
+		t.Fatalf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+// every malformed stream should fail
+func TestStreamErrors(t *testing.T) {
+	tests := []func(s *encode.Stream) error{
+		// value without a key
+		func(s *encode.Stream) error {
+			s.BeginMapping()
+			return s.Scalar(5)
+		},
+		// key outside of a mapping
+		func(s *encode.Stream) error {
+			s.BeginSequence()
+			return s.Key("a")
+		},
+		// two keys in a row
+		func(s *encode.Stream) error {
+			s.BeginMapping()
+			s.Key("a")
+			return s.Key("b")
+		},
+		// end after a key
+		func(s *encode.Stream) error {
+			s.BeginMapping()
+			s.Key("a")
+			return s.End()
+		},
+		// unbalanced end
+		func(s *encode.Stream) error {
+			return s.End()
+		},
+		// unbalanced begin
+		func(s *encode.Stream) error {
+			s.BeginMapping()
+			return s.Close()
+		},
+		// two document values
+		func(s *encode.Stream) error {
+			s.Scalar(1)
+			return s.Scalar(2)
+		},
+		// collections need begin and end
+		func(s *encode.Stream) error {
+			return s.Scalar([]int{1, 2})
+		},
+		// errors stick
+		func(s *encode.Stream) error {
+			s.End()
+			s.BeginMapping()
+			s.End()
+			return s.Close()
+		},
+	}
+	for i, test := range tests {
+		var buf strings.Builder
+		s := encode.MakeStream(&buf)
+		if e := test(&s); e == nil {
+			t.Errorf("test %d expected an error", i)
+		}
+	}
+}
@@ -2,19 +2,30 @@ package encode
 
 import (
 	"io"
-	"strconv"
+	"unicode/utf8"
 
 	"github.com/ionous/tell/runes"
 )
 
+// output is buffered;
+// it gets written to the writer once enough has built up, or on Flush().
+// ( the Encoder's Encode and Write methods flush when they're done;
+// anything else using a TabWriter directly needs to call Flush() itself. )
+// the first error from the writer sticks: all later writes are discarded,
+// and the error is available from Err() and Flush().
 type TabWriter struct {
 	depth    int // requested leading spaces on each new line
 	spaces   int // trailing spaces
 	newLines int // requested newlines, not written until pad()
 	Writer   io.Writer
 	xpos     int
+	buf      []byte // pending output
+	err      error  // the first error from the writer
 }
 
+// the amount of pending output which triggers a write
+const flushSize = 4096
+
 // a soft space -- eaten if theres a newline
 func (tab *TabWriter) Space() {
 	tab.spaces++
@@ -51,9 +62,9 @@ func (tab *TabWriter) pad() {
 	if n := tab.newLines; n > 0 {
 		tab.newLines = 0
 		for i := 0; i < n; i++ {
-			runes.WriteRune(tab.Writer, runes.Newline)
+			tab.buf = append(tab.buf, runes.Newline)
 		}
-		writeSpaces(tab.Writer, tab.depth)
+		tab.buf = appendSpaces(tab.buf, tab.depth)
 		tab.xpos = tab.depth
 	}
 	//
@@ -62,43 +73,108 @@ func (tab *TabWriter) pad() {
 
 func (tab *TabWriter) writeSpaces() {
 	if tab.spaces > 0 {
-		writeSpaces(tab.Writer, tab.spaces)
+		tab.buf = appendSpaces(tab.buf, tab.spaces)
 		tab.xpos += tab.spaces
 		tab.spaces = 0
 	}
 }
 
+// write any pending output to the writer.
+// returns the first error encountered while writing ( if any )
+func (tab *TabWriter) Flush() error {
+	if tab.err == nil && len(tab.buf) > 0 {
+		if n, e := tab.Writer.Write(tab.buf); e != nil {
+			tab.err = e
+		} else if n < len(tab.buf) {
+			tab.err = io.ErrShortWrite
+		}
+	}
+	tab.buf = tab.buf[:0]
+	return tab.err
+}
+
+// the first error encountered while writing ( if any )
+func (tab *TabWriter) Err() error {
+	return tab.err
+}
+
+// write once there's enough pending output
+func (tab *TabWriter) flushed(cnt int) (int, error) {
+	if len(tab.buf) >= flushSize {
+		tab.Flush()
+	}
+	return cnt, tab.err
+}
+
 // quotes and escapes the passed string.
-// fix? uses strconv, and strconv produces \x, \u, and \U escapes
-// ( plus it requires multiple traversals over the string )
+// ( write errors are reported by Err() )
 func (tab *TabWriter) Quote(s string) {
-	str := strconv.Quote(s)
-	tab.WriteString(str)
+	tab.pad()
+	start := len(tab.buf)
+	tab.buf = append(tab.buf, runes.QuoteDouble)
+	tab.buf, _ = appendEscaped(tab.buf, s)
+	tab.buf = append(tab.buf, runes.QuoteDouble)
+	tab.xpos += len(tab.buf) - start // approximate
+	tab.flushed(0)
 }
 
 // escape the contents of a string
-// returns true if there were any escapes
-func (tab *TabWriter) Escape(s string) bool {
-	str := strconv.Quote(s) // fix? strconv doesnt have a writer api
-	cnt, _ := tab.WriteString(str[1 : len(str)-1])
-	return cnt > len(s)
+// returns true if there were any escapes.
+// ( write errors are reported by Err() )
+func (tab *TabWriter) Escape(s string) (escaped bool) {
+	tab.pad()
+	start := len(tab.buf)
+	tab.buf, escaped = appendEscaped(tab.buf, s)
+	tab.xpos += len(tab.buf) - start // approximate
+	tab.flushed(0)
+	return
 }
 
 func (tab *TabWriter) WriteString(s string) (int, error) {
 	tab.pad()
 	tab.xpos += len(s) // approximate
-	return io.WriteString(tab.Writer, s)
+	tab.buf = append(tab.buf, s...)
+	return tab.flushed(len(s))
 }
 
 func (tab *TabWriter) WriteRune(q rune) (ret int, err error) {
 	tab.pad()
 	tab.xpos += 1 // approximate
-	return runes.WriteRune(tab.Writer, q)
+	start := len(tab.buf)
+	tab.buf = utf8.AppendRune(tab.buf, q)
+	return tab.flushed(len(tab.buf) - start)
 }
 
 func (tab *TabWriter) writeLine(str string) {
-	tab.WriteString(str)
-	tab.Softline()
+	if str == blankLine {
+		tab.blankLine()
+	} else {
+		tab.WriteString(str)
+		tab.Softline()
+	}
+}
+
+// start a new line, and write the passed text without any indentation.
+// ( ex. for the lines inside of a raw string. )
+func (tab *TabWriter) writeUnindented(line []byte) {
+	tab.newLines, tab.spaces = 0, 0
+	tab.buf = append(tab.buf, runes.Newline)
+	tab.buf = append(tab.buf, line...)
+	tab.xpos = len(line)
+	tab.flushed(len(line))
+}
+
+// a comment line containing only runes.BlankLine
+const blankLine = string(runes.BlankLine)
+
+// end the current line ( if anything was written on it ) and add an empty line.
+// ( the empty line doesn't get indented. )
+func (tab *TabWriter) blankLine() {
+	if tab.newLines == 0 && tab.xpos > 0 {
+		tab.newLines++
+	}
+	tab.newLines++
+	tab.spaces = 0
 }
 
 func (tab *TabWriter) writeLines(lines []string) {
@@ -107,9 +183,11 @@ func (tab *TabWriter) writeLines(lines []string) {
 	}
 }
 
-func writeSpaces(w io.Writer, cnt int) {
-	b := []byte{runes.Space} // what is the fast way to do this?
-	for i := 0; i < cnt; i++ {
-		w.Write(b)
+func appendSpaces(b []byte, cnt int) []byte {
+	for ; cnt > len(spaces); cnt -= len(spaces) {
+		b = append(b, spaces...)
 	}
+	return append(b, spaces[:cnt]...)
 }
+
+const spaces = "                                "
@@ -1,7 +1,12 @@
 package encode_test
 
 import (
+	"bytes"
 	_ "embed"
+	"errors"
+	"fmt"
+	r "reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -89,6 +94,98 @@ func lines(s ...string) string {
 	return strings.Join(s, "\n")
 }
 
+// quoting should match strconv for valid utf8
+func TestQuote(t *testing.T) {
+	tests := []string{
+		"hello",
+		"",
+		`back\slash`,
+		`"quoted"`,
+		"bell\a tab\t line\n",
+		"\x00\x7f\u0085",
+		"héllo wörld",
+		"\u2028 \U0001F600 \U000e0001",
+		strings.Repeat("long ", 1000),
+	}
+	for i, str := range tests {
+		var buf strings.Builder
+		tab := encode.TabWriter{Writer: &buf}
+		tab.Quote(str)
+		if e := tab.Flush(); e != nil {
+			t.Fatal(e)
+		} else if got, want := buf.String(), strconv.Quote(str); got != want {
+			t.Errorf("failed test %d\nhave %s\nwant %s", i, got, want)
+		}
+	}
+	// malformed utf8 becomes the replacement character
+	var buf strings.Builder
+	tab := encode.TabWriter{Writer: &buf}
+	tab.Quote("bad\xffbyte")
+	tab.Flush()
+	if got, want := buf.String(), `"bad\ufffdbyte"`; got != want {
+		t.Errorf("have %s want %s", got, want)
+	}
+}
+
+// the first write error should stop the encoder
+func TestWriteError(t *testing.T) {
+	src := make(map[string]any)
+	for i := 0; i < 1000; i++ {
+		src[fmt.Sprintf("key%d", i)] = []any{"some", "values", i}
+	}
+	w := failingWriter{limit: 2}
+	enc := encode.MakeEncoder(&w)
+	if e := enc.Encode(src); !errors.Is(e, errDiskFull) {
+		t.Fatal("expected a write error; have", e)
+	} else if w.calls != w.limit+1 {
+		t.Fatal("expected writing to stop; wrote", w.calls, "times")
+	}
+}
+
+// the encoder's write methods flush when they're done;
+// direct use of the TabWriter needs Flush, and reports write errors with Err.
+func TestFlush(t *testing.T) {
+	var buf strings.Builder
+	enc := encode.MakeEncoder(&buf)
+	if e := enc.WriteValue(r.ValueOf([]int{1, 2}), false); e != nil {
+		t.Fatal(e)
+	} else if have, want := buf.String(), "- 1\n- 2"; have != want {
+		t.Fatalf("have %q want %q", have, want)
+	}
+	var out bytes.Buffer
+	enc = encode.MakeEncoder(&out)
+	if it, e := enc.Mapper(r.ValueOf(map[string]any{"a": 1, "b": "two"})); e != nil {
+		t.Fatal(e)
+	} else if e := enc.WriteMapping(it, false); e != nil {
+		t.Fatal(e)
+	} else if have, want := out.String(), "a: 1\nb: \"two\""; have != want {
+		t.Fatalf("have %q want %q", have, want)
+	}
+	w := failingWriter{limit: 0}
+	tab := encode.TabWriter{Writer: &w}
+	if tab.Quote(strings.Repeat("long ", 1000)); !errors.Is(tab.Err(), errDiskFull) {
+		t.Fatal("expected a write error; have", tab.Err())
+	} else if tab.Escape("more"); !errors.Is(tab.Flush(), errDiskFull) {
+		t.Fatal("expected the error to stick; have", tab.Err())
+	}
+}
+
+var errDiskFull = errors.New("disk full")
+
+// fails after a certain number of writes
+type failingWriter struct {
+	limit, calls int
+}
+
+func (w *failingWriter) Write(b []byte) (ret int, err error) {
+	if w.calls++; w.calls > w.limit {
+		err = errDiskFull
+	} else {
+		ret = len(b)
+	}
+	return
+}
+
 // gofmt is problematic for strings ( and comments! )
 func chomp(s string) string {
 	return s[1:] + "\n"
@@ -2,8 +2,10 @@ package tell
 
 import (
 	"io"
+	"reflect"
 
 	"github.com/ionous/tell/encode"
+	"github.com/ionous/tell/note"
 )
 
 // Encoder - follows the pattern of encoding/json
@@ -24,6 +26,13 @@ func (enc *Encoder) Encode(v any) (err error) {
 	return inner.Encode(v)
 }
 
+// EncodeWithComments - like Encode, but includes a block of document comments.
+// ( ex. the comments collected by Decoder.UseNotes )
+func (enc *Encoder) EncodeWithComments(v any, block string) (err error) {
+	inner := (*encode.Encoder)(enc)
+	return inner.EncodeWithComments(v, block)
+}
+
 // configure how mappings are encoded
 // returns self for chaining
 func (enc *Encoder) SetMapper(n encode.StartCollection, c encode.Commenting) *Encoder {
@@ -41,3 +50,38 @@ func (enc *Encoder) SetSequencer(n encode.StartCollection, c encode.Commenting)
 	inner.SequenceComments = c
 	return enc
 }
+
+// configure how strings are quoted
+// returns self for chaining
+func (enc *Encoder) SetQuoteStyle(s encode.QuoteStyle) *Encoder {
+	inner := (*encode.Encoder)(enc)
+	inner.QuoteStyle = s
+	return enc
+}
+
+// allow infinite and not-a-number floats, writing them as inf, -inf, and nan.
+// otherwise, as per json, they are an error.
+// returns self for chaining
+func (enc *Encoder) SetInfNaN(allow bool) *Encoder {
+	inner := (*encode.Encoder)(enc)
+	inner.UseInfNaN = allow
+	return enc
+}
+
+// write values of the passed type as custom scalars.
+// see also: Decoder.UseScalars
+// returns self for chaining
+func (enc *Encoder) SetFormatter(t reflect.Type, f encode.Formatter) *Encoder {
+	inner := (*encode.Encoder)(enc)
+	inner.SetFormatter(t, f)
+	return enc
+}
+
+// write comments from the passed table, keyed by the path of each collection.
+// ( as generated by Decoder.UseSidecar )
+// returns self for chaining
+func (enc *Encoder) SetSidecar(s note.Sidecar) *Encoder {
+	inner := (*encode.Encoder)(enc)
+	inner.Sidecar = s
+	return enc
+}
@@ -0,0 +1,8 @@
+package note
+
+import "github.com/ionous/tell/collect"
+
+// Sidecar holds comment blocks keyed by the path of their collection;
+// an alternative to storing each comment block inside its collection.
+// ( the comments of a document scalar are stored at the root path. )
+type Sidecar map[collect.Path]string
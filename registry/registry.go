@@ -0,0 +1,266 @@
+// Package registry turns single key mappings into registered go types.
+// The key of each mapping is a signature ( ex. `Say:to:` ) which names a command,
+// and the value holds the command's arguments:
+// one argument for each word of the signature, in order.
+//
+//	Say:to:
+//	  - "hi"
+//	  - "bob"
+//
+// With `Say:to:` registered as `type Say struct { Text, Target string }`,
+// decoding that document produces Say{Text: "hi", Target: "bob"}.
+// A signature with a single word takes its value as its one argument: `Say: "hi"`.
+package registry
+
+import (
+	"fmt"
+	"math"
+	r "reflect"
+	"strings"
+
+	"github.com/ionous/tell/collect"
+	"github.com/ionous/tell/collect/imap"
+	"github.com/ionous/tell/decode"
+	"github.com/ionous/tell/token"
+)
+
+// Registry maps signatures to the types they construct.
+// the zero value is ready to use.
+type Registry struct {
+	types map[string]r.Type
+}
+
+// associate a signature with a struct type, or a pointer to a struct type.
+// ex. reg.Register("Say:to:", (*Say)(nil))
+// commands get created with the same type as the passed value;
+// its exported fields receive the arguments of the command in order.
+func (reg *Registry) Register(sig string, v any) (err error) {
+	t := r.TypeOf(v)
+	st := t
+	if t != nil && t.Kind() == r.Pointer {
+		st = t.Elem()
+	}
+	if parts := token.SplitSignature(sig); !validSignature(sig, parts) {
+		err = fmt.Errorf("invalid signature %q", sig)
+	} else if st == nil || st.Kind() != r.Struct {
+		err = fmt.Errorf("signature %q expected a struct, have %v", sig, t)
+	} else if cnt := len(exportedFields(st)); cnt != len(parts) {
+		err = fmt.Errorf("signature %q has %d parts, but %s has %d exported fields", sig, len(parts), st, cnt)
+	} else if _, exists := reg.types[sig]; exists {
+		err = fmt.Errorf("signature %q already registered", sig)
+	} else {
+		if reg.types == nil {
+			reg.types = make(map[string]r.Type)
+		}
+		reg.types[sig] = t
+	}
+	return
+}
+
+// signatures have at least one word, and end with a colon.
+func validSignature(sig string, parts []string) (okay bool) {
+	if okay = len(parts) > 0 && strings.HasSuffix(sig, ":"); okay {
+		for _, w := range parts {
+			if len(w) == 0 {
+				okay = false
+				break
+			}
+		}
+	}
+	return
+}
+
+// create the command registered for the passed signature,
+// binding the passed value as its arguments.
+func (reg *Registry) Make(sig string, args any) (ret any, err error) {
+	if t, ok := reg.types[sig]; !ok {
+		err = fmt.Errorf("unknown signature %q", sig)
+	} else {
+		ret, err = build(t, sig, args, false)
+	}
+	return
+}
+
+// a decode.Hook which replaces any mapping with a single, registered, key
+// with the command it describes. other values are left as is.
+// ex. dec.AddHook(reg.Hook)
+// commands are built as the decoder finishes each mapping,
+// so the arguments of a command can themselves be commands.
+// when the decoder keeps comments, they're skipped.
+func (reg *Registry) Hook(path collect.Path, at decode.Span, val any) (ret any, err error) {
+	ret = val
+	if sig, args, comments, ok := singleKey(val); ok {
+		if t, ok := reg.types[sig]; ok {
+			ret, err = build(t, sig, args, comments)
+		}
+	}
+	return
+}
+
+// the key and value of a mapping with exactly one key.
+// mappings which keep comments have a blank key for their comment block;
+// it doesn't count, but it means sequences keep comments in their first element.
+func singleKey(val any) (key string, el any, comments, okay bool) {
+	var cnt int
+	switch m := val.(type) {
+	case imap.ItemMap:
+		for _, it := range m {
+			if len(it.Key) == 0 {
+				comments = true
+			} else if cnt++; cnt == 1 {
+				key, el = it.Key, it.Value
+			}
+		}
+	default:
+		if v := r.ValueOf(val); v.Kind() == r.Map && v.Type().Key().Kind() == r.String {
+			for it := v.MapRange(); it.Next(); {
+				if k := it.Key().String(); len(k) == 0 {
+					comments = true
+				} else if cnt++; cnt == 1 {
+					key, el = k, it.Value().Interface()
+				}
+			}
+		}
+	}
+	okay = cnt == 1
+	return
+}
+
+// create a new value of type t from the passed arguments
+// ( if comments are kept, the first element of each sequence is skipped. )
+func build(t r.Type, sig string, args any, comments bool) (ret any, err error) {
+	st := t
+	if t.Kind() == r.Pointer {
+		st = t.Elem()
+	}
+	out := r.New(st)
+	fields := exportedFields(st)
+	if vals, e := splitArgs(args, len(fields), comments); e != nil {
+		err = fmt.Errorf("%s %w", sig, e)
+	} else {
+		for i, field := range fields {
+			if e := assign(out.Elem().Field(field), vals[i], comments); e != nil {
+				err = fmt.Errorf("%s argument %d: %w", sig, i+1, e)
+				break
+			}
+		}
+	}
+	if err == nil {
+		if t.Kind() == r.Pointer {
+			ret = out.Interface()
+		} else {
+			ret = out.Elem().Interface()
+		}
+	}
+	return
+}
+
+// a command with one argument uses its value as that argument;
+// otherwise, the value has to be a sequence with one element per argument.
+func splitArgs(args any, cnt int, comments bool) (ret []any, err error) {
+	if cnt == 1 {
+		ret = []any{args}
+	} else if v := r.ValueOf(args); v.Kind() != r.Slice {
+		err = fmt.Errorf("expected %d arguments, have %T", cnt, args)
+	} else if start := firstElement(v, comments); v.Len()-start != cnt {
+		err = fmt.Errorf("expected %d arguments, have %d", cnt, v.Len()-start)
+	} else {
+		ret = make([]any, cnt)
+		for i := range ret {
+			ret[i] = v.Index(start + i).Interface()
+		}
+	}
+	return
+}
+
+// the index of the first value in a sequence:
+// sequences which keep comments store them in their first element.
+func firstElement(v r.Value, comments bool) (ret int) {
+	if comments && v.Len() > 0 {
+		ret = 1
+	}
+	return
+}
+
+// set dst to the passed decoded value
+// converting numbers, and the elements of sequences, as needed.
+func assign(dst r.Value, val any, comments bool) (err error) {
+	src := r.ValueOf(val)
+	switch {
+	case val == nil:
+		dst.SetZero()
+	case src.Kind() == r.Slice && dst.Kind() == r.Slice && comments:
+		start := firstElement(src, comments)
+		err = assign(dst, src.Slice(start, src.Len()).Interface(), false)
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+	case isNumber(src.Kind()) && isNumber(dst.Kind()):
+		if lossyNumber(src, dst) {
+			err = fmt.Errorf("can't store %v in %s", val, dst.Type())
+		} else {
+			dst.Set(src.Convert(dst.Type()))
+		}
+	case src.Kind() == r.Slice && dst.Kind() == r.Slice:
+		n := src.Len()
+		els := r.MakeSlice(dst.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if e := assign(els.Index(i), src.Index(i).Interface(), comments); e != nil {
+				err = fmt.Errorf("element %d: %w", i, e)
+				break
+			}
+		}
+		if err == nil {
+			dst.Set(els)
+		}
+	default:
+		err = fmt.Errorf("can't assign %T to %s", val, dst.Type())
+	}
+	return
+}
+
+func isNumber(k r.Kind) bool {
+	return (k >= r.Int && k <= r.Uint64) || k == r.Float32 || k == r.Float64
+}
+
+// would converting src to the type of dst change its value?
+// ex. a fraction stored in an int, a negative number in a uint, or a number too large for its type.
+func lossyNumber(src, dst r.Value) (lossy bool) {
+	signed := dst.Kind() >= r.Int && dst.Kind() <= r.Int64
+	unsigned := dst.Kind() >= r.Uint && dst.Kind() <= r.Uint64
+	switch k := src.Kind(); {
+	case k >= r.Int && k <= r.Int64:
+		i := src.Int()
+		if signed {
+			lossy = dst.OverflowInt(i)
+		} else if unsigned {
+			lossy = i < 0 || dst.OverflowUint(uint64(i))
+		}
+	case k >= r.Uint && k <= r.Uint64:
+		u := src.Uint()
+		if signed {
+			lossy = u > math.MaxInt64 || dst.OverflowInt(int64(u))
+		} else if unsigned {
+			lossy = dst.OverflowUint(u)
+		}
+	default:
+		f := src.Float()
+		if signed {
+			lossy = f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || dst.OverflowInt(int64(f))
+		} else if unsigned {
+			lossy = f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || dst.OverflowUint(uint64(f))
+		} else {
+			lossy = dst.OverflowFloat(f)
+		}
+	}
+	return
+}
+
+// the indices of the exported fields of the passed struct type
+func exportedFields(t r.Type) (ret []int) {
+	for i, cnt := 0, t.NumField(); i < cnt; i++ {
+		if t.Field(i).IsExported() {
+			ret = append(ret, i)
+		}
+	}
+	return
+}
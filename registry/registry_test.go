@@ -0,0 +1,153 @@
+package registry_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell/collect/imap"
+	"github.com/ionous/tell/collect/stdmap"
+	"github.com/ionous/tell/collect/stdseq"
+	"github.com/ionous/tell/decode"
+	"github.com/ionous/tell/note"
+	"github.com/ionous/tell/registry"
+)
+
+type Say struct {
+	Text   string
+	Target string
+}
+
+type Repeat struct {
+	Count float64
+	Do    []any
+}
+
+type Tags struct {
+	Names []string
+}
+
+type Move struct {
+	Steps int
+	Speed uint8
+}
+
+func newRegistry(t *testing.T) (reg registry.Registry) {
+	if e := reg.Register("Say:to:", Say{}); e != nil {
+		t.Fatal(e)
+	} else if e := reg.Register("Repeat:do:", (*Repeat)(nil)); e != nil {
+		t.Fatal(e)
+	} else if e := reg.Register("Tags:", Tags{}); e != nil {
+		t.Fatal(e)
+	} else if e := reg.Register("Move:speed:", Move{}); e != nil {
+		t.Fatal(e)
+	}
+	return
+}
+
+// registered signatures become commands; everything else stays as is.
+func TestRegistry(t *testing.T) {
+	const doc = "- Say:to:\n" +
+		"    - \"hi\"\n" +
+		"    - \"bob\"\n" +
+		"- Repeat:do:\n" +
+		"    - 2\n" +
+		"    - - Say:to: [\"hello\", \"alice\"]\n" +
+		"- Tags: [\"a\", \"b\"]\n" +
+		"- Unknown: 5\n"
+	reg := newRegistry(t)
+	want := []any{
+		Say{Text: "hi", Target: "bob"},
+		&Repeat{Count: 2, Do: []any{
+			Say{Text: "hello", Target: "alice"},
+		}},
+		Tags{Names: []string{"a", "b"}},
+		map[string]any{"Unknown:": 5},
+	}
+	// works with both ordered and unordered maps
+	for i, ordered := range []bool{false, true} {
+		var dec decode.Decoder
+		dec.SetSequencer(stdseq.Make)
+		if !ordered {
+			dec.SetMapper(stdmap.Make)
+		} else {
+			dec.SetMapper(imap.Make)
+			want[3] = imap.ItemMap{{Key: "Unknown:", Value: 5}}
+		}
+		dec.AddHook(reg.Hook)
+		if have, e := dec.Decode(strings.NewReader(doc)); e != nil {
+			t.Fatal(e)
+		} else if !reflect.DeepEqual(have, want) {
+			t.Fatalf("test %d have %#v", i, have)
+		}
+	}
+}
+
+// comments are kept in a blank key, and in the first element of each sequence;
+// neither are arguments.
+func TestRegistryComments(t *testing.T) {
+	const doc = "- Say:to:\n" +
+		"    # greeting\n" +
+		"    - \"hi\"\n" +
+		"    - \"bob\" # who\n" +
+		"- Tags: [\"a\", \"b\"]\n" +
+		"- Move:speed: [2.0, 3]\n"
+	reg := newRegistry(t)
+	var notes note.Book
+	var dec decode.Decoder
+	dec.SetMapper(stdmap.Make)
+	dec.SetSequencer(stdseq.Make)
+	dec.UseNotes(&notes)
+	dec.AddHook(reg.Hook)
+	if have, e := dec.Decode(strings.NewReader(doc)); e != nil {
+		t.Fatal(e)
+	} else if els := have.([]any); len(els) != 4 ||
+		els[1] != (Say{Text: "hi", Target: "bob"}) ||
+		!reflect.DeepEqual(els[2], Tags{Names: []string{"a", "b"}}) ||
+		els[3] != (Move{Steps: 2, Speed: 3}) {
+		t.Fatalf("have %#v", have)
+	}
+}
+
+// commands with the wrong arguments are reported where they appear.
+func TestRegistryErrors(t *testing.T) {
+	reg := newRegistry(t)
+	for i, doc := range []string{
+		"Say:to: \"hi\"\n",
+		"Say:to: [\"hi\"]\n",
+		"Say:to: [\"hi\", 5]\n",
+		"- Repeat:do: [\"twice\", []]\n",
+		// numbers which don't fit their fields
+		"Move:speed: [1.5, 2]\n",
+		"Move:speed: [1, -2]\n",
+		"Move:speed: [1, 300]\n",
+		"Move:speed: [1e20, 2]\n",
+	} {
+		var dec decode.Decoder
+		dec.SetMapper(stdmap.Make)
+		dec.SetSequencer(stdseq.Make)
+		dec.AddHook(reg.Hook)
+		var pos decode.ErrorPos
+		if _, e := dec.Decode(strings.NewReader(doc)); !errors.As(e, &pos) {
+			t.Errorf("test %d expected a positioned error, have %v", i, e)
+		} else {
+			t.Log("ok", e)
+		}
+	}
+	// bad registrations:
+	for i, test := range []struct {
+		sig string
+		v   any
+	}{
+		{"Say", Say{}},      // missing colon
+		{"Say:", Say{}},     // too few parts
+		{"Say::", Say{}},    // empty part
+		{"Say:to:", "text"}, // not a struct
+		{"Say:to:", Say{}},  // already registered
+	} {
+		if e := reg.Register(test.sig, test.v); e == nil {
+			t.Errorf("test %d expected a registration error", i)
+		}
+	}
+}
@@ -4,8 +4,9 @@ const (
 	ArrayClose     = ']'
 	ArrayOpen      = '['
 	ArraySeparator = ','
-	Colon          = ':' // keywords in a signature are separated by a colon
-	Dash           = '-' // values in a sequence are prefixed by a dash ( and whitespace )
+	BlankLine      = '\v' // in comment blocks, vertical tab records a blank line.
+	Colon          = ':'  // keywords in a signature are separated by a colon
+	Dash           = '-'  // values in a sequence are prefixed by a dash ( and whitespace )
 	Eof            = -1
 	Escape         = '\\'
 	Hash           = '#'  // comment marker
@@ -54,9 +54,8 @@ func Marshal(v any) (ret []byte, err error) {
 //
 // For more flexibility, see package decode
 func Unmarshal(in []byte, pv any) (err error) {
-	dec := Decoder{
-		src:   bytes.NewReader(in),
-		inner: makeDefaultDecoder(),
-	}
-	return dec.Decode(pv)
+	dec := Decoder{inner: makeDefaultDecoder()}
+	return dec.decode(pv, func() (any, error) {
+		return dec.inner.DecodeBytes(in)
+	})
 }
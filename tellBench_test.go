@@ -0,0 +1,127 @@
+package tell_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell"
+	"github.com/ionous/tell/testdata"
+)
+
+// compare unmarshaling from a slice with decoding from a stream.
+// go test -bench=. -benchmem
+func BenchmarkUnmarshal(b *testing.B) {
+	tellFiles, jsonFiles := readBenchFiles(b)
+	b.Run("unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, src := range tellFiles {
+				var res any
+				if e := tell.Unmarshal(src, &res); e != nil {
+					b.Fatal(e)
+				}
+			}
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, src := range tellFiles {
+				var res any
+				dec := tell.NewDecoder(bytes.NewReader(src))
+				if e := dec.Decode(&res); e != nil {
+					b.Fatal(e)
+				}
+			}
+		}
+	})
+	// for reference
+	b.Run("json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, src := range jsonFiles {
+				var res any
+				if e := json.Unmarshal(src, &res); e != nil {
+					b.Fatal(e)
+				}
+			}
+		}
+	})
+}
+
+// the tell test files which have matching json
+func readBenchFiles(b *testing.B) (tellFiles, jsonFiles [][]byte) {
+	if files, e := testdata.Tell.ReadDir("."); e != nil {
+		b.Fatal(e)
+	} else {
+		for _, info := range files {
+			tellName := info.Name()
+			jsonName := tellName[:len(tellName)-4] + "json"
+			if strings.HasPrefix(tellName, "x_") {
+				continue
+			} else if src, e := fs.ReadFile(testdata.Tell, tellName); e != nil {
+				b.Fatal(e)
+			} else if js, e := fs.ReadFile(testdata.Json, jsonName); e != nil {
+				b.Fatal(e)
+			} else {
+				tellFiles = append(tellFiles, src)
+				jsonFiles = append(jsonFiles, js)
+			}
+		}
+	}
+	return
+}
+
+// compare marshaling a large map with encoding/json
+// go test -bench=Marshal -benchmem
+func BenchmarkMarshal(b *testing.B) {
+	src := makeBenchMap(1000)
+	b.Run("marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, e := tell.Marshal(src); e != nil {
+				b.Fatal(e)
+			}
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			enc := tell.NewEncoder(io.Discard)
+			if e := enc.Encode(src); e != nil {
+				b.Fatal(e)
+			}
+		}
+	})
+	// for reference
+	b.Run("json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, e := json.Marshal(src); e != nil {
+				b.Fatal(e)
+			}
+		}
+	})
+}
+
+// something like a saved game:
+// lots of entries containing a mix of values
+func makeBenchMap(cnt int) map[string]any {
+	out := make(map[string]any, cnt)
+	for i := 0; i < cnt; i++ {
+		out[fmt.Sprintf("object %d", i)] = map[string]any{
+			"name":     fmt.Sprintf("name %d", i),
+			"desc":     "a \"quoted\" description\twith escapes",
+			"visited":  i%2 == 0,
+			"count":    i,
+			"weight":   float64(i) * 0.25,
+			"children": []any{"one", "two", "three", i},
+		}
+	}
+	return out
+}
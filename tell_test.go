@@ -1,6 +1,23 @@
 package tell
 
-import "testing"
+import (
+	"io/fs"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/ionous/tell/collect"
+	"github.com/ionous/tell/collect/orderedmap"
+	"github.com/ionous/tell/decode"
+	"github.com/ionous/tell/encode"
+	"github.com/ionous/tell/note"
+	"github.com/ionous/tell/testdata"
+	"github.com/ionous/tell/token"
+)
 
 // minimal testing of the simplified Marshal function.
 // ( more extensive testing of decode exists in TestFiles and package decode )
@@ -20,3 +37,495 @@ func TestUnmarshal(t *testing.T) {
 		t.Fatal("expected value")
 	}
 }
+
+// numbers too large to fit should be reported as errors, not panics;
+// unless the decoder is asked to preserve them.
+func TestLargeNumbers(t *testing.T) {
+	const doc = "- 5\n- 99999999999999999999\n"
+	var res []any
+	if e := Unmarshal([]byte(doc), &res); e == nil {
+		t.Fatal("expected an error")
+	} else if !strings.Contains(e.Error(), "out of range") {
+		t.Fatal("unexpected error", e)
+	} else if pos, ok := e.(decode.ErrorPos); !ok {
+		t.Fatal("expected a positioned error")
+	} else if y, _ := pos.Pos(); y != 1 {
+		t.Fatal("unexpected line", y)
+	}
+	//
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.UseBigNumbers()
+	if e := dec.Decode(&res); e != nil {
+		t.Fatal(e)
+	} else if b, ok := res[1].(*big.Int); !ok || b.String() != "99999999999999999999" {
+		t.Fatalf("unexpected value %v(%T)", res[1], res[1])
+	}
+	//
+	dec = NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	if e := dec.Decode(&res); e != nil {
+		t.Fatal(e)
+	} else if !reflect.DeepEqual(res, []any{Number("5"), Number("99999999999999999999")}) {
+		t.Fatalf("unexpected values %v", res)
+	}
+}
+
+// infinity and not-a-number are opt-in.
+func TestInfNaN(t *testing.T) {
+	src := []any{math.Inf(1), math.Inf(-1), math.NaN()}
+	if _, e := Marshal(src); e == nil {
+		t.Fatal("expected an error")
+	}
+	var buf strings.Builder
+	if e := NewEncoder(&buf).SetInfNaN(true).Encode(src); e != nil {
+		t.Fatal(e)
+	} else if str := buf.String(); str != "- inf\n- -inf\n- nan\n" {
+		t.Fatal("unexpected output", str)
+	} else {
+		var res []any
+		dec := NewDecoder(strings.NewReader(str))
+		dec.UseInfNaN()
+		if e := dec.Decode(&res); e != nil {
+			t.Fatal(e)
+		} else if len(res) != 3 || !math.IsInf(res[0].(float64), 1) ||
+			!math.IsInf(res[1].(float64), -1) || !math.IsNaN(res[2].(float64)) {
+			t.Fatal("unexpected values", res)
+		}
+	}
+}
+
+// numbers decoded as text should be written back as they were spelled.
+func TestNumberRoundTrip(t *testing.T) {
+	const doc = "- 0x20\n- 1e-3\n- 1_000\n- -5\n- +5\n- 0b1010\n- 0o755\n- 0x1.8p1\n- 072.40\n"
+	var res []any
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	if e := dec.Decode(&res); e != nil {
+		t.Fatal(e)
+	} else if out, e := Marshal(res); e != nil {
+		t.Fatal(e)
+	} else if str := string(out); str != doc {
+		t.Fatalf("have:\n%s\nwant:\n%s", str, doc)
+	} else if v, e := res[0].(Number).Value(); e != nil || v != uint(0x20) {
+		t.Fatal("unexpected value", v, e)
+	}
+	// numbers have to be numbers
+	if _, e := Marshal(Number("five")); e == nil {
+		t.Fatal("expected an error")
+	} else if _, e := Marshal(Number("-inf")); e == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+// custom scalars should survive a round trip
+func TestCustomScalars(t *testing.T) {
+	src := map[string]any{"Delay:": 90 * time.Second, "Items:": []any{time.Minute, 5}}
+	var buf strings.Builder
+	enc := NewEncoder(&buf).SetFormatter(reflect.TypeOf(time.Duration(0)), func(v reflect.Value) (string, error) {
+		return time.Duration(v.Int()).String(), nil
+	})
+	if e := enc.Encode(src); e != nil {
+		t.Fatal(e)
+	} else if str := buf.String(); str != "Delay: 1m30s\nItems:\n  - 1m0s\n  - 5\n" {
+		t.Fatal("unexpected output", str)
+	} else {
+		var res map[string]any
+		dec := NewDecoder(strings.NewReader(str))
+		dec.UseScalars(token.TextScalar(unicode.IsDigit, time.ParseDuration))
+		if e := dec.Decode(&res); e != nil {
+			t.Fatal(e)
+		} else if !reflect.DeepEqual(res, src) {
+			t.Fatal("mismatched", res)
+		}
+	}
+}
+
+// heredocs should keep their language and closing tag
+func TestHeredocRoundTrip(t *testing.T) {
+	const doc = "Code: ```go\n" +
+		"    package main\n" +
+		"    ```\n" +
+		"Notes: \"\"\"markdown\n" +
+		"    # title\\tescaped\n" +
+		"    \"\"\"\n" +
+		"Piped: |\n" +
+		"    line\n" +
+		"    '''\n" +
+		"Script: '''lua <<<END\n" +
+		"    print(\"'''\")\n" +
+		"    END\n" +
+		"Tagged: ```<<<EOF\n" +
+		"    text\n" +
+		"    EOF\n"
+	var res map[string]any
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.UseHeredocs()
+	if e := dec.Decode(&res); e != nil {
+		t.Fatal(e)
+	} else if have, want := res["Code:"], (Heredoc{Lang: "go", Style: '`', Text: "package main\n"}); have != want {
+		t.Fatalf("have %#v want %#v", have, want)
+	} else if have, want := res["Script:"], (Heredoc{Lang: "lua", Tag: "END", Style: '\'', Text: "print(\"'''\")"}); have != want {
+		t.Fatalf("have %#v want %#v", have, want)
+	} else if out, e := Marshal(res); e != nil {
+		t.Fatal(e)
+	} else if str := string(out); str != doc {
+		t.Fatalf("have:\n%s\nwant:\n%s", str, doc)
+	}
+	// the text of a heredoc should match the plain string
+	var plain map[string]any
+	if e := Unmarshal([]byte(doc), &plain); e != nil {
+		t.Fatal(e)
+	} else {
+		for k, v := range res {
+			if str := v.(Heredoc).Text; str != plain[k] {
+				t.Errorf("%s mismatched text %q", k, str)
+			}
+		}
+	}
+	// languages are single words
+	if _, e := Marshal(Heredoc{Lang: "go lang", Text: "x"}); e == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+// blank lines should survive a round trip when keeping comments
+func TestBlankLines(t *testing.T) {
+	const doc = "# header\n" +
+		"\n" +
+		"Alpha: 1\n" +
+		"\n" +
+		"# about beta\n" +
+		"Beta:\n" +
+		"  - 1\n" +
+		"\n" +
+		"  - 2\n" +
+		"\n" +
+		"\n" +
+		"  # two blanks\n" +
+		"  - 3\n" +
+		"Zeta:\n" +
+		"\n" +
+		"  Inner: 5\n"
+	var res any
+	var docComments note.Book
+	dec := NewDecoder(strings.NewReader(doc + "\n\n"))
+	dec.UseNotes(&docComments)
+	dec.UseBlankLines()
+	var buf strings.Builder
+	enc := encode.MakeCommentEncoder(&buf)
+	if e := dec.Decode(&res); e != nil {
+		t.Fatal(e)
+	} else if e := enc.Encode(res); e != nil {
+		t.Fatal(e)
+	} else if str := buf.String(); str != doc {
+		t.Fatalf("have:\n%s\nwant:\n%s", str, doc)
+	}
+	// without comments, blank lines have no effect.
+	var plain any
+	dec = NewDecoder(strings.NewReader(doc + "Array: [1,\n\n  2]\n"))
+	dec.UseBlankLines()
+	if e := dec.Decode(&plain); e != nil {
+		t.Fatal(e)
+	} else if m := plain.(map[string]any); len(m) != 4 || m["Alpha:"] != 1 {
+		t.Fatal("unexpected", plain)
+	}
+	// blank lines within arrays are ignored.
+	dec = NewDecoder(strings.NewReader("- [1,\n\n  2]\n"))
+	dec.UseNotes(&docComments)
+	dec.UseBlankLines()
+	if e := dec.Decode(&plain); e != nil {
+		t.Fatal(e)
+	} else if have, want := plain, []any{"", []any{"", 1, 2}}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("have %#v want %#v", have, want)
+	}
+}
+
+// blank lines between a key and its value mustn't end the value.
+func TestBlankLinesBeforeValues(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want any
+	}{{
+		// a sequence at the same indent as its key:
+		// the blank line becomes a header of the first element.
+		"Key:\n\n- 1\n",
+		map[string]any{"": "", "Key:": []any{"\v", 1}},
+	}, {
+		// there's nowhere to record a blank line before a scalar.
+		"Key: \n\n  5\n",
+		map[string]any{"": "", "Key:": 5},
+	}}
+	for i, test := range tests {
+		var res any
+		var docComments note.Book
+		dec := NewDecoder(strings.NewReader(test.doc))
+		dec.UseNotes(&docComments)
+		dec.UseBlankLines()
+		if e := dec.Decode(&res); e != nil {
+			t.Fatal(i, e)
+		} else if !reflect.DeepEqual(res, test.want) {
+			t.Fatalf("test %d have %#v want %#v", i, res, test.want)
+		}
+	}
+}
+
+// comments kept in a sidecar should survive a round trip
+// without changing the shape of the decoded values.
+func TestSidecar(t *testing.T) {
+	b, e := fs.ReadFile(testdata.Tell, "smallCatalogComments.tell")
+	if e != nil {
+		t.Fatal(e)
+	}
+	doc := string(b)
+	var res orderedmap.OrderedMap
+	table := make(note.Sidecar)
+	dec := NewDecoder(strings.NewReader(doc))
+	dec.SetMapper(orderedmap.Make) // comments are positional; so keys need to stay in order.
+	dec.UseSidecar(table)
+	var buf strings.Builder
+	if e := dec.Decode(&res); e != nil {
+		t.Fatal(e)
+	} else if keys := res.Keys(); len(keys) != 1 || keys[0] != "catalog:" {
+		t.Fatal("unexpected keys", keys)
+	} else if cat, _ := res.Get("catalog:"); len(cat.([]any)) != 2 {
+		t.Fatal("unexpected catalog", cat)
+	} else if str := table[collect.MakePath("catalog:", 0, "options:")]; str != "\r# Inline prefix\f\r\r\n# Trailing suffix" {
+		t.Fatalf("unexpected comment %q", str)
+	} else if e := NewEncoder(&buf).SetSidecar(table).Encode(res); e != nil {
+		t.Fatal(e)
+	} else if str := buf.String(); str != doc {
+		t.Fatalf("have:\n%s\nwant:\n%s", str, doc)
+	}
+}
+
+// document comments should survive a round trip
+func TestDocComments(t *testing.T) {
+	files := []string{
+		"docComments1", "docComments2", "docComments3", "docComments4",
+		"docComments5", "docCommentsNested", "docScalarComments", "emptyDocComments",
+	}
+	for _, name := range files {
+		if b, e := fs.ReadFile(testdata.Tell, name+".tell"); e != nil {
+			t.Fatal(e)
+		} else if want, block, e := decodeWithComments(string(b)); e != nil {
+			t.Fatal(name, e)
+		} else {
+			var buf strings.Builder
+			enc := encode.MakeCommentEncoder(&buf)
+			if e := enc.EncodeWithComments(want, block); e != nil {
+				t.Fatal(name, e)
+			} else if have, haveBlock, e := decodeWithComments(buf.String()); e != nil {
+				t.Fatal(name, e)
+			} else if !reflect.DeepEqual(have, want) || haveBlock != block {
+				t.Fatalf("%s mismatched\n%s", name, buf.String())
+			}
+		}
+	}
+	// a scalar with header and footer
+	var buf strings.Builder
+	if e := NewEncoder(&buf).EncodeWithComments(5, "# header\r\r# suffix\f# footer"); e != nil {
+		t.Fatal(e)
+	} else if have, want := buf.String(), "# header\n5 # suffix\n# footer\n"; have != want {
+		t.Fatalf("have:\n%s\nwant:\n%s", have, want)
+	}
+	// the sidecar stores the comments of a document scalar at its root.
+	var res any
+	table := make(note.Sidecar)
+	dec := NewDecoder(strings.NewReader(buf.String()))
+	dec.UseSidecar(table)
+	var out strings.Builder
+	if e := dec.Decode(&res); e != nil {
+		t.Fatal(e)
+	} else if e := NewEncoder(&out).SetSidecar(table).Encode(res); e != nil {
+		t.Fatal(e)
+	} else if have, want := out.String(), buf.String(); have != want {
+		t.Fatalf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+func decodeWithComments(str string) (ret any, block string, err error) {
+	var book note.Book
+	dec := NewDecoder(strings.NewReader(str))
+	dec.UseNotes(&book)
+	if e := dec.Decode(&ret); e != nil {
+		err = e
+	} else {
+		block, _ = book.Resolve()
+	}
+	return
+}
+
+// values at matching paths should hold their source text;
+// which can be decoded on its own, or written back out.
+func TestRawValues(t *testing.T) {
+	const doc = "# shared header\n" +
+		"Name: \"game\"\n" +
+		"Plugins:\n" +
+		"  Physics:\n" +
+		"    # gravity in m/s\n" +
+		"    Gravity: 9.8 # down\n" +
+		"    Layers:\n" +
+		"      - \"ground\"\n" +
+		"      - [1, 2]\n" +
+		"  Script: |\n" +
+		"    print(\"hi\")\n" +
+		"\n" +
+		"      indented\n" +
+		"    '''\n" +
+		"  Empty:\n" +
+		"  Count: 5 # how many\n" +
+		"Version: 2\n"
+	want := map[string]RawValue{
+		"Physics:": RawValue("# gravity in m/s\n" +
+			"Gravity: 9.8 # down\n" +
+			"Layers:\n" +
+			"  - \"ground\"\n" +
+			"  - [1, 2]"),
+		"Script:": RawValue("|\n    print(\"hi\")\n\n      indented\n    '''"),
+		"Empty:":  RawValue(""),
+		"Count:":  RawValue("5 # how many"),
+	}
+	isPlugin := func(p collect.Path) bool {
+		parts := p.Parts()
+		return len(parts) == 2 && parts[0] == "Plugins:"
+	}
+	// decoding from a stream and from bytes should both work
+	for i, decode := range []func(*Decoder, *map[string]any) error{
+		func(dec *Decoder, out *map[string]any) error {
+			return dec.Decode(out)
+		},
+		func(dec *Decoder, out *map[string]any) error {
+			dec.inner.RawValues = isPlugin
+			return dec.decode(out, func() (any, error) {
+				return dec.inner.DecodeBytes([]byte(doc))
+			})
+		},
+	} {
+		var res map[string]any
+		dec := NewDecoder(strings.NewReader(doc))
+		dec.UseRawValues(isPlugin)
+		if e := decode(dec, &res); e != nil {
+			t.Fatal(i, e)
+		}
+		plugins := res["Plugins:"].(map[string]any)
+		for k, v := range want {
+			if raw, ok := plugins[k].(RawValue); !ok {
+				t.Fatalf("%d: expected a raw value for %s, have %T", i, k, plugins[k])
+			} else if string(raw) != string(v) {
+				t.Fatalf("%d: %s\nhave: %q\nwant: %q", i, k, raw, v)
+			}
+		}
+		if res["Version:"] != 2 {
+			t.Fatal("unexpected version", res["Version:"])
+		}
+	}
+	// raw values are documents of their own
+	var physics map[string]any
+	if e := Unmarshal(want["Physics:"], &physics); e != nil {
+		t.Fatal(e)
+	} else if physics["Gravity:"] != 9.8 {
+		t.Fatal("unexpected gravity", physics)
+	}
+	var script string
+	if e := Unmarshal(want["Script:"], &script); e != nil {
+		t.Fatal(e)
+	} else if script != "print(\"hi\")\n\n  indented" {
+		t.Fatalf("unexpected script %q", script)
+	}
+	// and get written back out as they were
+	const out = "Plugins:\n" +
+		"  Count: 5 # how many\n" +
+		"  Physics:\n" +
+		"    # gravity in m/s\n" +
+		"    Gravity: 9.8 # down\n" +
+		"    Layers:\n" +
+		"      - \"ground\"\n" +
+		"      - [1, 2]\n" +
+		"  Script: |\n" +
+		"    print(\"hi\")\n" +
+		"\n" +
+		"      indented\n" +
+		"    '''\n" +
+		"Version: 2\n"
+	if b, e := Marshal(map[string]any{
+		"Plugins": map[string]any{
+			"Physics": want["Physics:"],
+			"Script":  want["Script:"],
+			"Count":   want["Count:"],
+		},
+		"Version": 2,
+	}); e != nil {
+		t.Fatal(e)
+	} else if str := string(b); str != out {
+		t.Fatalf("have:\n%s\nwant:\n%s", str, out)
+	}
+	// the lines inside of raw strings keep their spaces
+	for i, test := range []struct {
+		doc, key string
+		raw      RawValue
+		want     any
+	}{{
+		doc:  "A:\n  B: `one\n      two`\n  C: 1",
+		key:  "A:",
+		raw:  RawValue("B: `one\n      two`\nC: 1"),
+		want: map[string]any{"B:": "one\n      two", "C:": 1},
+	}, {
+		doc:  "B: `line one\nline two`",
+		key:  "B:",
+		raw:  RawValue("`line one\nline two`"),
+		want: "line one\nline two",
+	}} {
+		isTop := func(p collect.Path) bool {
+			return len(p.Parts()) == 1
+		}
+		for j, decode := range []func(*Decoder) (any, error){
+			func(dec *Decoder) (any, error) {
+				return dec.inner.Decode(dec.src)
+			},
+			func(dec *Decoder) (any, error) {
+				return dec.inner.DecodeBytes([]byte(test.doc))
+			},
+		} {
+			var val any
+			dec := NewDecoder(strings.NewReader(test.doc))
+			dec.UseRawValues(isTop)
+			if res, e := decode(dec); e != nil {
+				t.Fatal(i, j, e)
+			} else if raw := res.(map[string]any)[test.key]; !reflect.DeepEqual(raw, test.raw) {
+				t.Fatalf("%d.%d\nhave: %q\nwant: %q", i, j, raw, test.raw)
+			} else if e := Unmarshal(test.raw, &val); e != nil {
+				t.Fatal(i, j, e)
+			} else if !reflect.DeepEqual(val, test.want) {
+				t.Fatalf("%d.%d decoded\nhave: %q\nwant: %q", i, j, val, test.want)
+			}
+		}
+		if b, e := Marshal(map[string]any{test.key[:len(test.key)-1]: test.raw}); e != nil {
+			t.Fatal(i, e)
+		} else if str := string(b); str != test.doc+"\n" {
+			t.Fatalf("%d written\nhave: %q\nwant: %q", i, str, test.doc)
+		}
+	}
+	// the elements of sequences can be raw too
+	var els []any
+	dec := NewDecoder(strings.NewReader("- a: 1\n  b: 2\n- First:\n  - 3\n  Second: 4\n"))
+	dec.UseRawValues(func(p collect.Path) bool {
+		return len(p.Parts()) == 1
+	})
+	if e := dec.Decode(&els); e != nil {
+		t.Fatal(e)
+	} else if !reflect.DeepEqual(els, []any{
+		RawValue("a: 1\nb: 2"),
+		RawValue("First:\n- 3\nSecond: 4"),
+	}) {
+		t.Fatalf("unexpected elements %q", els)
+	}
+	// decoding into a raw value keeps the whole document
+	var raw RawValue
+	if e := Unmarshal([]byte(doc), &raw); e != nil {
+		t.Fatal(e)
+	} else if str := string(raw); str != strings.TrimSpace(doc) {
+		t.Fatalf("unexpected document %q", str)
+	} else if e := Unmarshal([]byte("a: 1\n b: 2\n"), &raw); e == nil {
+		t.Fatal("expected an error for an invalid document")
+	}
+}
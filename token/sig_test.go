@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -73,3 +74,25 @@ func TestSig(t *testing.T) {
 		t.Fatal(e)
 	}
 }
+
+func TestSplitSignature(t *testing.T) {
+	for _, test := range []struct {
+		sig  string
+		want []string
+	}{
+		{"", nil},
+		{"a:", []string{"a"}},
+		{"Say:to:", []string{"Say", "to"}},
+		{"and:more complex:keys_like_this:", []string{"and", "more complex", "keys_like_this"}},
+	} {
+		if have := token.SplitSignature(test.sig); !reflect.DeepEqual(have, test.want) {
+			t.Errorf("%q have %q", test.sig, have)
+		}
+	}
+	var sig token.Signature
+	if e := parseString("Say:to:", sig.Decoder()); e != io.EOF {
+		t.Fatal(e)
+	} else if have := sig.Parts(); !reflect.DeepEqual(have, []string{"Say", "to"}) {
+		t.Fatal("unexpected parts", have)
+	}
+}
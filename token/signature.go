@@ -20,6 +20,21 @@ type Signature struct {
 // for now defined as unicode is letter, but might be useful to be more lenient
 var isValidSignaturePrefix = unicode.IsLetter
 
+// the words of the signature, without their colons.
+// ex. `Say:to:` has the parts "Say" and "to".
+func (sig *Signature) Parts() []string {
+	return SplitSignature(sig.String())
+}
+
+// split a signature ( ex. a map key ) into its colon separated words.
+// returns nil for an empty signature.
+func SplitSignature(sig string) (ret []string) {
+	if str := strings.TrimSuffix(sig, string(runes.Colon)); len(str) > 0 {
+		ret = strings.Split(str, string(runes.Colon))
+	}
+	return
+}
+
 func (sig *Signature) Pending() bool {
 	return sig.lastSep == 0 || (sig.lastSep < sig.Len())
 }
@@ -0,0 +1,165 @@
+package token
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ionous/tell/charm"
+	"github.com/ionous/tell/runes"
+)
+
+// ScalarFactory recognizes a custom kind of scalar value ( ex. dates, colors. )
+// Returns a state to read the runes of a possible value, and a function to produce that value.
+//
+// The state is sent the first rune of each token, and every rune after that.
+// To match, the state must read at least one rune, and then return unhandled (nil)
+// on the whitespace ( or array separator ) following the value;
+// the value function must then succeed.
+// On a match, the tokenizer reports the value as a Custom token.
+// Otherwise, the tokenizer reads the same runes as one of its standard tokens.
+type ScalarFactory func() (charm.State, func() (any, error))
+
+// a factory for scalars which can be read as a single word:
+// all the runes until the next whitespace ( or array separator. )
+// the first rune must pass the "first" test; parse produces the value, or an error.
+//
+// custom scalars are tried before the standard values, so any word parse accepts
+// stops being a number ( or a bool, etc. ) for example, time.ParseDuration accepts "0";
+// so TextScalar(unicode.IsDigit, time.ParseDuration) would read a plain `0` as a duration.
+// to keep numbers as numbers, parse should reject them. for example:
+//
+//	TextScalar(unicode.IsDigit, func(s string) (ret time.Duration, err error) {
+//		if strings.IndexFunc(s, unicode.IsLetter) < 0 {
+//			err = errors.New("expected a unit") // a plain number
+//		} else {
+//			ret, err = time.ParseDuration(s)
+//		}
+//		return
+//	})
+func TextScalar[T any](first func(rune) bool, parse func(string) (T, error)) ScalarFactory {
+	return func() (charm.State, func() (any, error)) {
+		var b strings.Builder
+		state := charm.Statement("text scalar", func(q rune) (ret charm.State) {
+			if first(q) {
+				b.WriteRune(q)
+				ret = charm.Self("text", func(self charm.State, q rune) (ret charm.State) {
+					if !isScalarEnd(q) {
+						b.WriteRune(q)
+						ret = self
+					}
+					return
+				})
+			}
+			return
+		})
+		return state, func() (any, error) {
+			return parse(b.String())
+		}
+	}
+}
+
+// runes which can follow a custom scalar
+func isScalarEnd(q rune) (okay bool) {
+	switch q {
+	case runes.Space, runes.Newline, runes.Eof, runes.ArraySeparator, runes.ArrayClose:
+		okay = true
+	}
+	return
+}
+
+type scalarMatch struct {
+	state charm.State
+	value func() (any, error)
+}
+
+func (n *tokenizer) startScalars() []scalarMatch {
+	list := make([]scalarMatch, len(n.Scalars))
+	for i, f := range n.Scalars {
+		list[i].state, list[i].value = f()
+	}
+	return list
+}
+
+// send the rune to each of the remaining scalars.
+// returns the value of the first to match ( if any ),
+// and the number which are still reading.
+func updateScalars(list []scalarMatch, q rune, first bool) (ret any, okay bool, alive int) {
+	for i, el := range list {
+		if el.state != nil {
+			if next := el.state.NewRune(q); next == nil {
+				list[i].state = nil
+				if !first && isScalarEnd(q) {
+					if v, e := el.value(); e == nil {
+						ret, okay = v, true
+						break
+					}
+				}
+			} else if terminal(next) {
+				list[i].state = nil
+			} else {
+				list[i].state = next
+				alive++
+			}
+		}
+	}
+	return
+}
+
+// try the custom scalars, buffering runes until one matches or they all fail.
+// if they fail, the buffered runes are replayed for the standard tokens.
+func (n *tokenizer) customScalars(q rune) charm.State {
+	type buffered struct {
+		q  rune
+		at Pos
+	}
+	var buf []buffered
+	list := n.startScalars()
+	var next func(q rune) charm.State
+	next = func(q rune) (ret charm.State) {
+		buf = append(buf, buffered{q, n.curr})
+		if v, ok, alive := updateScalars(list, q, len(buf) == 1); ok {
+			ret = n.notifyRune(q, Custom, v)
+		} else if alive > 0 && q != runes.Eof {
+			ret = charm.Statement("custom scalars", next)
+		} else {
+			// replay using the positions of the buffered runes
+			// ( the tokenizer's cursor continues from the current rune )
+			curr := n.curr
+			ret = charm.Statement("builtin", n.builtin)
+			for _, el := range buf {
+				n.curr = el.at
+				if ret = ret.NewRune(el.q); ret == nil {
+					break
+				}
+			}
+			n.curr = curr
+		}
+		return
+	}
+	return next(q)
+}
+
+// try the custom scalars directly on the source.
+// returns false if none of them matched.
+func (s *Scanner) scanScalars(start Pos) (okay bool, err error) {
+	list := s.n.startScalars()
+	for ofs, first := s.ofs, true; ; first = false {
+		q, size := rune(runes.Eof), 0
+		if ofs < len(s.src) {
+			q, size = utf8.DecodeRune(s.src[ofs:])
+		}
+		if v, ok, alive := updateScalars(list, q, first); ok {
+			// advance over the value
+			for s.ofs < ofs {
+				q, size := s.peek()
+				s.advance(q, size)
+			}
+			okay, err = true, s.notify(start, Custom, v)
+			break
+		} else if alive == 0 || q == runes.Eof {
+			break
+		}
+		ofs += size
+	}
+	return
+}
@@ -0,0 +1,122 @@
+package token_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/ionous/tell/charm"
+	"github.com/ionous/tell/runes"
+	"github.com/ionous/tell/token"
+)
+
+type color uint32
+
+// a #rrggbb color; a hash followed by a space is still a comment.
+func colorScalar() (charm.State, func() (any, error)) {
+	var digits []byte
+	state := charm.Statement("color", func(q rune) (ret charm.State) {
+		if q == runes.Hash {
+			ret = charm.Self("rgb", func(self charm.State, q rune) (ret charm.State) {
+				if runes.IsHex(q) && len(digits) < 6 {
+					digits = append(digits, byte(q))
+					ret = self
+				}
+				return
+			})
+		}
+		return
+	})
+	return state, func() (ret any, err error) {
+		if len(digits) != 6 {
+			err = errors.New("expected six digits")
+		} else if v, e := strconv.ParseUint(string(digits), 16, 32); e != nil {
+			err = e
+		} else {
+			ret = color(v)
+		}
+		return
+	}
+}
+
+// durations need units; otherwise, they're numbers.
+func parseDuration(s string) (ret time.Duration, err error) {
+	if strings.IndexFunc(s, unicode.IsLetter) < 0 {
+		err = errors.New("expected a unit")
+	} else {
+		ret, err = time.ParseDuration(s)
+	}
+	return
+}
+
+var testScalars = []token.ScalarFactory{
+	colorScalar,
+	token.TextScalar(unicode.IsDigit, parseDuration),
+	token.TextScalar(unicode.IsDigit, func(s string) (time.Time, error) {
+		return time.Parse(time.DateOnly, s)
+	}),
+}
+
+func TestScalars(t *testing.T) {
+	date, _ := time.Parse(time.DateOnly, "2024-02-29")
+	tests := []struct {
+		str    string
+		expect []any
+	}{
+		{"#ff8000", []any{color(0xff8000)}},
+		{"# comment", []any{"# comment"}},
+		{"#ff80", []any{"#ff80"}}, // not a color, so its a comment.
+		{"5m30s", []any{5*time.Minute + 30*time.Second}},
+		{"5", []any{5}},
+		// numbers still decode as numbers:
+		{"[0, 1.5, 1e3, 0x20, -2]", []any{'[', 0, ',', 1.5, ',', 1000.0, ',', uint(0x20), ',', -2, ']'}},
+		{"0s", []any{time.Duration(0)}},
+		{"2024-02-29", []any{date}},
+		{"[5s, 0x20,#000001]", []any{'[', 5 * time.Second, ',', uint(0x20), ',', color(1), ']'}},
+		{"Key: 1h\n- 12\n- \"str\"", []any{"Key:", time.Hour, "", 12, "", "str"}},
+	}
+	for i, test := range tests {
+		var have, want results
+		cfg := token.Tokenizer{Notifier: &want, Scalars: testScalars}
+		if e := tokenizeStrictly(test.str, cfg); e != nil {
+			if test.expect != nil {
+				t.Errorf("test %d %q failed %s", i, test.str, e)
+			}
+			continue
+		} else if test.expect == nil {
+			t.Errorf("test %d %q expected failure", i, test.str)
+			continue
+		}
+		var vals []any
+		for _, el := range want {
+			vals = append(vals, el.tokenValue)
+		}
+		if !reflect.DeepEqual(vals, test.expect) {
+			t.Errorf("test %d %q\nhave: %v\nwant: %v", i, test.str, vals, test.expect)
+		}
+		// the scanner should match the tokenizer
+		cfg.Notifier = &have
+		if e := cfg.Scanner([]byte(test.str)).Scan(); e != nil {
+			t.Errorf("test %d %q scanner failed %s", i, test.str, e)
+		} else if !reflect.DeepEqual(have, want) {
+			t.Errorf("test %d %q scanner mismatch\nhave: %v\nwant: %v", i, test.str, have, want)
+		}
+	}
+}
+
+// when the custom scalars dont match, the tokens should be the same as without them.
+func TestScalarReplay(t *testing.T) {
+	const doc = "Key: 5\n  - 0x20 # comment\n  - \"10 minutes\"\nNext: [1, 2.5]\n"
+	var have, want results
+	if e := tokenize(doc, token.Tokenizer{Notifier: &want}); e != nil {
+		t.Fatal(e)
+	} else if e := tokenize(doc, token.Tokenizer{Notifier: &have, Scalars: testScalars}); e != nil {
+		t.Fatal(e)
+	} else if !reflect.DeepEqual(have, want) {
+		t.Fatalf("mismatch\nhave: %v\nwant: %v", have, want)
+	}
+}
@@ -0,0 +1,322 @@
+package token
+
+import (
+	"errors"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ionous/tell/charm"
+	"github.com/ionous/tell/charmed"
+	"github.com/ionous/tell/runes"
+)
+
+// Scanner reports the same tokens as the Tokenizer
+// but reads from a slice of bytes rather than a stream of runes.
+// It handles common tokens directly, and hands anything more complicated
+// ( ex. heredocs, escaped strings, hex numbers ) to the tokenizer's states.
+type Scanner struct {
+	n    tokenizer
+	src  []byte
+	ofs  int  // byte offset of the next rune
+	curr Pos  // position of the next rune
+	done bool // set by the tokenizer after it reports a token
+	line bool // true if the current line has a token
+}
+
+// return a scanner for the passed document.
+func (cfg Tokenizer) Scanner(src []byte) *Scanner {
+	s := &Scanner{n: tokenizer{Tokenizer: cfg}, src: src}
+	s.n.after = s.finished
+	return s
+}
+
+// the position of the scanner;
+// after an error, this is ( roughly ) the point of failure.
+func (s *Scanner) Pos() Pos {
+	return s.curr
+}
+
+// the byte offset of the scanner;
+// while reporting a token, this is the end of that token.
+func (s *Scanner) Offset() int {
+	return s.ofs
+}
+
+// read the whole document, reporting tokens to the notifier as it goes.
+func (s *Scanner) Scan() (err error) {
+	for err == nil && s.ofs < len(s.src) {
+		switch q := rune(s.src[s.ofs]); q {
+		case runes.Space:
+			s.advance(q, 1)
+		case runes.Newline:
+			if !s.line && s.n.UseBlankLines {
+				s.n.blanks++
+			}
+			s.line = false
+			s.advance(q, 1)
+		default:
+			if e := s.n.blankLines(s.curr); e != nil {
+				err = e
+			} else {
+				s.line = true
+				err = s.scanToken()
+			}
+		}
+	}
+	return
+}
+
+// called by the tokenizer states after reporting a token;
+// returns unhandled to return control to the scanner.
+func (s *Scanner) finished(rune) charm.State {
+	s.done = true
+	return nil
+}
+
+// peek at the next rune
+// returns runes.Eof at the end of input.
+func (s *Scanner) peek() (ret rune, size int) {
+	if s.ofs >= len(s.src) {
+		ret = runes.Eof
+	} else if q := s.src[s.ofs]; q < utf8.RuneSelf {
+		ret, size = rune(q), 1
+	} else {
+		ret, size = utf8.DecodeRune(s.src[s.ofs:])
+	}
+	return
+}
+
+// move past the passed rune
+func (s *Scanner) advance(q rune, size int) {
+	s.ofs += size
+	if q == runes.Newline {
+		s.curr.Y++
+		s.curr.X = 0
+	} else {
+		s.curr.X++
+	}
+}
+
+func (s *Scanner) notify(start Pos, t Type, v any) error {
+	return s.n.Notifier.Decoded(start, t, v)
+}
+
+// the start of a token; non-whitespace and not at the end of the input.
+func (s *Scanner) scanToken() (err error) {
+	start := s.curr
+	if len(s.n.Scalars) > 0 {
+		if ok, e := s.scanScalars(start); ok || e != nil {
+			return e
+		}
+	}
+	switch q, size := s.peek(); q {
+	case runes.HTab:
+		err = errors.New("tabs are invalid whitespace")
+
+	case runes.Hash:
+		err = s.scanComment(start)
+
+	case runes.QuoteDouble, runes.QuoteSingle, runes.QuoteRaw:
+		err = s.scanQuote(start, q)
+
+	case runes.Dash:
+		// a dash followed by whitespace is a sequence; otherwise it's a number.
+		if next := s.ofs + 1; next == len(s.src) || s.src[next] == runes.Space || s.src[next] == runes.Newline {
+			s.advance(q, size)
+			err = s.notify(start, Key, "")
+		} else {
+			err = s.scanNumber(start)
+		}
+
+	case runes.ArrayOpen, runes.ArrayClose, runes.ArraySeparator:
+		s.advance(q, size)
+		err = s.notify(start, Array, q)
+
+	default:
+		switch {
+		case charmed.IsInvalidRune(q):
+			err = charm.InvalidRune(q)
+		case runes.IsNumber(q) || q == '+':
+			err = s.scanNumber(start)
+		case unicode.IsLetter(q):
+			err = s.scanWord(start, q)
+		case q == runes.QuotePipe:
+			err = s.fallback()
+		default:
+			err = charm.InvalidRune(q)
+		}
+	}
+	return
+}
+
+// a comment runs until the end of the line.
+func (s *Scanner) scanComment(start Pos) (err error) {
+	from := s.ofs
+	for {
+		if q, size := s.peek(); q == runes.Newline || q == runes.Eof {
+			err = s.notify(start, Comment, string(s.src[from:s.ofs]))
+			break
+		} else if q == utf8.RuneError && size == 1 {
+			// let the tokenizer decide how to handle malformed text
+			s.ofs, s.curr = from, start
+			err = s.fallback()
+			break
+		} else if charmed.IsInvalidRune(q) {
+			err = charm.InvalidRune(q)
+			break
+		} else {
+			s.advance(q, size)
+		}
+	}
+	return
+}
+
+// a string that fits on a single line, without escapes, is handled here;
+// everything else is handed to the tokenizer.
+func (s *Scanner) scanQuote(start Pos, match rune) (err error) {
+	end, cnt := s.ofs+1, 1 // the byte after the opening quote, and the number of runes so far.
+	for {
+		if end >= len(s.src) {
+			end = -1 // an unterminated string; let the tokenizer report on it.
+			break
+		} else if b := s.src[end]; b == byte(match) {
+			// an empty string might be the start of a heredoc.
+			if next := end + 1; end == s.ofs+1 && next < len(s.src) && s.src[next] == byte(match) {
+				end = -1
+			}
+			break
+		} else if b == runes.Escape && match == runes.QuoteDouble {
+			end = -1
+			break
+		} else if b < utf8.RuneSelf {
+			if b == runes.Newline || charmed.IsInvalidRune(rune(b)) {
+				end = -1
+				break
+			}
+			end, cnt = end+1, cnt+1
+		} else if q, size := utf8.DecodeRune(s.src[end:]); (q == utf8.RuneError && size == 1) || charmed.IsInvalidRune(q) {
+			end = -1
+			break
+		} else {
+			end, cnt = end+size, cnt+1
+		}
+	}
+	if end < 0 {
+		err = s.fallback()
+	} else {
+		str := string(s.src[s.ofs+1 : end])
+		s.ofs = end + 1
+		s.curr.X += cnt + 1
+		err = s.notify(start, String, str)
+	}
+	return
+}
+
+// simple decimal numbers are handled here;
+// everything else is handed to the tokenizer.
+func (s *Scanner) scanNumber(start Pos) (err error) {
+	var d charmed.NumParser
+	if width := d.ScanDecimal(s.src[s.ofs:]); width == 0 {
+		err = s.fallback()
+	} else {
+		// move past the number first, so errors are reported at its end
+		// ( the same as the tokenizer. )
+		s.ofs += width
+		s.curr.X += width // decimal numbers are ascii
+		if v, e := s.n.numValue(&d); e != nil {
+			err = e
+		} else {
+			err = s.notify(start, Number, v)
+		}
+	}
+	return
+}
+
+// literals ( ex. booleans ) and signatures
+func (s *Scanner) scanWord(start Pos, q rune) (err error) {
+	if word, tokenType, val := s.n.literal(q); len(word) == 0 || !s.hasWord(word) {
+		err = s.scanSignature(start)
+	} else {
+		s.ofs += len(word)
+		s.curr.X += len(word)
+		err = s.notify(start, tokenType, val)
+	}
+	return
+}
+
+// does the input continue with the passed word followed by whitespace?
+func (s *Scanner) hasWord(str string) (okay bool) {
+	if end := s.ofs + len(str); end <= len(s.src) && string(s.src[s.ofs:end]) == str {
+		okay = end == len(s.src) || s.src[end] == runes.Space || s.src[end] == runes.Newline
+	}
+	return
+}
+
+// follows the same rules as Signature: words separated by colons,
+// ending with a colon and whitespace.
+func (s *Scanner) scanSignature(start Pos) (err error) {
+	from := s.ofs
+	var lastSep int // offset after the most recent colon
+	for first := true; ; first = false {
+		q, size := s.peek()
+		pending := lastSep == 0 || lastSep < s.ofs
+		if first {
+			if !isValidSignaturePrefix(q) {
+				err = errWordy
+				break
+			}
+		} else if runes.IsWhitespace(q) && !pending {
+			err = s.notify(start, Key, string(s.src[from:s.ofs]))
+			break
+		} else if q == runes.Colon {
+			if !pending {
+				err = errWordy
+				break
+			}
+			lastSep = s.ofs + size
+		} else if q == runes.Space || q == runes.Underscore || q == runes.Dash || unicode.IsDigit(q) {
+			if !pending {
+				err = errWordy
+				break
+			}
+		} else if !unicode.IsLetter(q) {
+			// includes newlines and eof when pending
+			err = errWordy
+			break
+		}
+		s.advance(q, size)
+	}
+	return
+}
+
+// run the tokenizer's states on the runes of the next token.
+// ( custom scalars have already been tried )
+func (s *Scanner) fallback() (err error) {
+	s.done = false
+	s.n.start = s.curr
+	next := charm.Statement("tokenize", s.n.builtin)
+	for {
+		q, size := s.peek()
+		if charmed.IsInvalidRune(q) {
+			err = charm.InvalidRune(q)
+			break
+		}
+		s.n.curr = s.curr
+		if next = next.NewRune(q); next == nil {
+			if !s.done {
+				err = charm.InvalidRune(q)
+			}
+			break
+		} else if es, ok := next.(charm.Terminal); ok {
+			if !es.Finished() {
+				err = es.Unwrap()
+			}
+			break
+		} else if q == runes.Eof {
+			err = charm.InvalidRune(q)
+			break
+		}
+		s.advance(q, size)
+	}
+	return
+}
@@ -0,0 +1,219 @@
+package token_test
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ionous/tell/charm"
+	"github.com/ionous/tell/charmed"
+	"github.com/ionous/tell/testdata"
+	"github.com/ionous/tell/token"
+)
+
+// the scanner should produce the same tokens as the tokenizer
+func TestScanner(t *testing.T) {
+	tests := []string{
+		`true`,
+		`false`,
+		`5`,
+		`-5`,
+		`+5`,
+		`0x20`,
+		`5.4`,
+		`-5.4e-3`,
+		`"5.4"`,
+		`"hello\\world"`,
+		"`" + `hello\\world` + "`",
+		`'single'`,
+		`""`,
+		`"héllo wörld"`,
+		"# comment",
+		"# comment\n# another",
+		"-",
+		"- - 5",
+		"hello:world:",
+		"hello world: 5",
+		"[1,,2, \"hello\"]",
+		"[ true ]",
+		"\"\"\"\nhello\ndoc\n\"\"\"",
+		"|\nyaml compatibility block\n'''",
+		"```\nhello\nline\n```",
+		"- \"\"\"\n  indented\n  \"\"\"\n- 5",
+		"Key:\n  - true\n  - \"string\" # comment\nNext: 5\n",
+	}
+	for i, str := range tests {
+		if e := compareScanner(str, false); e != nil {
+			t.Errorf("failed test %d %q: %s", i, str, e)
+		}
+	}
+	if files, e := testdata.Tell.ReadDir("."); e != nil {
+		t.Fatal(e)
+	} else {
+		for _, info := range files {
+			name := info.Name()
+			if b, e := fs.ReadFile(testdata.Tell, name); e != nil {
+				t.Fatal(e)
+			} else if e := compareScanner(string(b), true); e != nil {
+				t.Errorf("failed %s: %s", name, e)
+			}
+		}
+	}
+}
+
+// the scanner and tokenizer should fail on the same input
+// ( though not always with the same message )
+func TestScannerErrors(t *testing.T) {
+	tests := []string{
+		"beep",
+		"falsey",
+		"true]",
+		"5x",
+		"@",
+		"\tfive",
+		"\"unterminated",
+		"_private: 5",
+		"hello:world",
+		"\r",
+	}
+	for i, str := range tests {
+		var pairs results
+		cfg := token.Tokenizer{Notifier: &pairs}
+		if e := cfg.Scanner([]byte(str)).Scan(); e == nil {
+			t.Errorf("failed test %d %q: expected an error", i, str)
+		} else if e := tokenizeStrictly(str, cfg); e == nil {
+			t.Errorf("failed test %d %q: tokenizer succeeded", i, str)
+		}
+	}
+}
+
+func compareScanner(str string, useFloats bool) (err error) {
+	var want, have results
+	if e := tokenize(str, token.Tokenizer{Notifier: &want, UseFloats: useFloats}); e != nil {
+		err = fmt.Errorf("tokenizer failed %w", e)
+	} else {
+		cfg := token.Tokenizer{Notifier: &have, UseFloats: useFloats}
+		if e := cfg.Scanner([]byte(str)).Scan(); e != nil {
+			err = fmt.Errorf("scanner failed %w", e)
+		} else if !reflect.DeepEqual(have, want) {
+			err = fmt.Errorf("mismatched tokens\nwant: %v\nhave: %v", want, have)
+		}
+	}
+	return
+}
+
+func tokenize(str string, cfg token.Tokenizer) error {
+	p := charm.MakeParser(strings.NewReader(str))
+	return p.ParseEof(cfg.Decode())
+}
+
+// like the decoder, reject control codes and runes the tokenizer doesn't handle.
+func tokenizeStrictly(str string, cfg token.Tokenizer) error {
+	p := charm.MakeParser(strings.NewReader(str))
+	return p.ParseEof(charm.Parallel("strictly",
+		charmed.FilterInvalidRunes(),
+		cfg.Decode(),
+	))
+}
+
+// inf and nan are only numbers when enabled.
+func TestInfNaN(t *testing.T) {
+	tests := []struct {
+		str    string
+		expect float64
+	}{
+		{"inf", math.Inf(1)},
+		{"+inf", math.Inf(1)},
+		{"-inf", math.Inf(-1)},
+		{"nan", math.NaN()},
+	}
+	for _, test := range tests {
+		for _, scan := range []bool{false, true} {
+			var pairs results
+			cfg := token.Tokenizer{Notifier: &pairs, UseInfNaN: true}
+			if e := scanOrTokenize(test.str, cfg, scan); e != nil {
+				t.Errorf("%q failed %s", test.str, e)
+			} else if len(pairs) != 1 || pairs[0].tokenType != token.Number {
+				t.Errorf("%q unexpected tokens %v", test.str, pairs)
+			} else if f := pairs[0].tokenValue.(float64); f != test.expect && !(math.IsNaN(f) && math.IsNaN(test.expect)) {
+				t.Errorf("%q unexpected value %v", test.str, f)
+			}
+			cfg.UseInfNaN = false
+			if e := scanOrTokenize(test.str, cfg, scan); e == nil {
+				t.Errorf("%q expected an error when disabled", test.str)
+			}
+		}
+	}
+	// words starting with inf and nan are still keys
+	if e := compareScanner("info: nano: infinity:", false); e != nil {
+		t.Error(e)
+	}
+}
+
+func scanOrTokenize(str string, cfg token.Tokenizer, scan bool) (err error) {
+	if scan {
+		err = cfg.Scanner([]byte(str)).Scan()
+	} else {
+		err = tokenizeStrictly(str, cfg)
+	}
+	return
+}
+
+// heredocs report their header when enabled.
+func TestHeredocs(t *testing.T) {
+	tests := []struct {
+		str    string
+		expect charmed.Heredoc
+	}{
+		{"```go\nfunc\n```", charmed.Heredoc{Lang: "go", Style: '`', Text: "func\n"}},
+		{"'''lua <<<END\nprint\nEND", charmed.Heredoc{Lang: "lua", Tag: "END", Style: '\'', Text: "print"}},
+		{"\"\"\"<<<END\ntext\nEND", charmed.Heredoc{Tag: "END", Style: '"', Text: "text\n"}},
+		{"|\npipe\n'''", charmed.Heredoc{Style: '|', Text: "pipe"}},
+	}
+	for _, test := range tests {
+		for _, scan := range []bool{false, true} {
+			var pairs results
+			cfg := token.Tokenizer{Notifier: &pairs, UseHeredocs: true}
+			if e := scanOrTokenize(test.str, cfg, scan); e != nil {
+				t.Errorf("%q failed %s", test.str, e)
+			} else if len(pairs) != 1 || pairs[0].tokenValue != test.expect {
+				t.Errorf("%q unexpected tokens %#v", test.str, pairs)
+			}
+		}
+	}
+	// regular strings stay strings
+	var pairs results
+	cfg := token.Tokenizer{Notifier: &pairs, UseHeredocs: true}
+	if e := tokenizeStrictly(`"hello"`, cfg); e != nil {
+		t.Error(e)
+	} else if len(pairs) != 1 || pairs[0].tokenValue != "hello" {
+		t.Errorf("unexpected tokens %#v", pairs)
+	}
+}
+
+// blank lines are reported as empty comments at the column of the next token.
+func TestBlankLines(t *testing.T) {
+	const str = "\na: 5\n\n  \n  # note\n- \"\"\"\n\n  \"\"\"\n\n"
+	want := results{
+		{token.Pos{Y: 0}, token.Comment, ""},
+		{token.Pos{Y: 1}, token.Key, "a:"},
+		{token.Pos{X: 3, Y: 1}, token.Number, 5},
+		{token.Pos{X: 2, Y: 2}, token.Comment, ""},
+		{token.Pos{X: 2, Y: 3}, token.Comment, ""},
+		{token.Pos{X: 2, Y: 4}, token.Comment, "# note"},
+		{token.Pos{Y: 5}, token.Key, ""},
+		{token.Pos{X: 2, Y: 5}, token.String, "\n"},
+	}
+	for _, scan := range []bool{false, true} {
+		var have results
+		cfg := token.Tokenizer{Notifier: &have, UseBlankLines: true}
+		if e := scanOrTokenize(str, cfg, scan); e != nil {
+			t.Fatal(e)
+		} else if !reflect.DeepEqual(have, want) {
+			t.Fatalf("scan %v mismatched tokens\nwant: %v\nhave: %v", scan, want, have)
+		}
+	}
+}
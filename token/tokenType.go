@@ -11,4 +11,5 @@ const (
 	Key     // an empty key means a sequence; otherwise a mapping
 	Number
 	String
+	Custom // a value produced by one of the tokenizer's ScalarFactory
 )
@@ -2,6 +2,8 @@ package token
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"strings"
 	"unicode"
 
@@ -20,6 +22,20 @@ type Tokenizer struct {
 	// configure the upcoming Decode to produce only floating point numbers.
 	// otherwise it will produce int for integers, and unit for hex specifications.
 	UseFloats bool // controls number decoding
+	// configure the values produced for numbers;
+	// when NumberText, UseFloats has no effect.
+	NumberMode charmed.NumberMode
+	// allow the words inf, +inf, -inf, and nan as numbers.
+	UseInfNaN bool
+	// custom scalar values; tried in order, before the standard tokens.
+	Scalars []ScalarFactory
+	// report heredocs as charmed.Heredoc values, rather than as plain strings.
+	UseHeredocs bool
+	// report blank lines as comments with empty text.
+	// ( real comments always start with a hash. )
+	// the blank lines are reported just before the token which follows them,
+	// using that token's column; blank lines at the end of a document are ignored.
+	UseBlankLines bool
 }
 
 // return a state to parse a stream of runes and notify as they are detected.
@@ -36,6 +52,10 @@ func NewTokenizer(n Notifier) charm.State {
 type tokenizer struct {
 	Tokenizer
 	curr, start Pos
+	blanks      int // blank lines since the last token
+	// when set, handles the rune following a token
+	// ( otherwise, the tokenizer continues on to the next token. )
+	after func(q rune) charm.State
 }
 
 func (n *tokenizer) decode(afterIndent bool) charm.State {
@@ -48,6 +68,8 @@ func (n *tokenizer) decode(afterIndent bool) charm.State {
 func (n *tokenizer) notifyRune(q rune, t Type, v any) (ret charm.State) {
 	if e := n.Notifier.Decoded(n.start, t, v); e != nil {
 		ret = charm.Error(e)
+	} else if n.after != nil {
+		ret = n.after(q)
 	} else {
 		ret = send(n.decode(true), q)
 	}
@@ -65,6 +87,9 @@ func (n *tokenizer) whitespace(afterIndent bool) charm.State {
 			spaces++
 			ret = self
 		case runes.Newline:
+			if !afterIndent && n.UseBlankLines {
+				n.blanks++
+			}
 			spaces = 0
 			afterIndent = false
 			ret = self
@@ -78,53 +103,79 @@ func (n *tokenizer) whitespace(afterIndent bool) charm.State {
 func (n *tokenizer) tokenize() charm.State {
 	return charm.Statement("tokenize", func(q rune) (ret charm.State) {
 		n.start = n.curr
-		switch q {
-		case runes.HTab:
-			ret = charm.Error(errors.New("tabs are invalid whitespace"))
-		case runes.Hash:
-			next := n.commentDecoder()
-			ret = send(next, q)
+		if e := n.blankLines(n.curr); e != nil {
+			ret = charm.Error(e)
+		} else if len(n.Scalars) > 0 {
+			ret = n.customScalars(q)
+		} else {
+			ret = n.builtin(q)
+		}
+		return
+	})
+}
 
-		case runes.QuoteDouble:
-			ret = n.decodeQuote(charmed.DecodeDouble)
-		case runes.QuoteSingle:
-			ret = n.decodeQuote(charmed.DecodeSingle)
-		case runes.QuoteRaw:
-			ret = n.decodeQuote(charmed.DecodeRaw)
-		case runes.QuotePipe:
-			ret = n.decodeQuote(charmed.DecodePipe)
+// report any pending blank lines as empty comments
+// positioned at the passed column of their own lines.
+// ( all of the lines between the previous token and the next are blank. )
+func (n *tokenizer) blankLines(next Pos) (err error) {
+	for i := n.blanks; i > 0 && err == nil; i-- {
+		err = n.Notifier.Decoded(Pos{X: next.X, Y: next.Y - i}, Comment, "")
+	}
+	n.blanks = 0
+	return
+}
 
-		case runes.Dash: // negative numbers or sequences
-			ret = n.dashDecoding()
+// the standard tokens
+func (n *tokenizer) builtin(q rune) (ret charm.State) {
+	switch q {
+	case runes.HTab:
+		ret = charm.Error(errors.New("tabs are invalid whitespace"))
+	case runes.Hash:
+		next := n.commentDecoder()
+		ret = send(next, q)
 
-		case runes.ArrayOpen, runes.ArrayClose, runes.ArraySeparator:
-			if e := n.Notifier.Decoded(n.start, Array, q); e != nil {
-				ret = charm.Error(e)
-			} else {
-				ret = n.decode(true)
-			}
+	case runes.QuoteDouble, runes.QuoteSingle, runes.QuoteRaw, runes.QuotePipe:
+		ret = n.decodeQuote(q)
 
-		default:
-			switch {
-			case runes.IsNumber(q) || q == '+': // a leading negative gets handled by dashDecoding.
-				next := n.numDecoder()
-				ret = send(next, q)
+	case runes.Dash: // negative numbers or sequences
+		ret = n.dashDecoding()
 
-			case unicode.IsLetter(q): // maps and bools
-				next := n.wordDecoder()
-				ret = send(next, q)
-			}
+	case runes.ArrayOpen, runes.ArrayClose, runes.ArraySeparator:
+		if e := n.Notifier.Decoded(n.start, Array, q); e != nil {
+			ret = charm.Error(e)
+		} else {
+			ret = n.decode(true)
 		}
-		return
-	})
-}
 
-type quoteParser func(*strings.Builder) charm.State
+	default:
+		switch {
+		case runes.IsNumber(q) || q == '+': // a leading negative gets handled by dashDecoding.
+			next := n.numDecoder()
+			ret = send(next, q)
+
+		case unicode.IsLetter(q): // maps and bools
+			next := n.wordDecoder()
+			ret = send(next, q)
+		}
+	}
+	return
+}
 
-func (n *tokenizer) decodeQuote(which quoteParser) charm.State {
+// the passed rune is the opening quote
+func (n *tokenizer) decodeQuote(quote rune) charm.State {
 	var b strings.Builder
-	return charm.Step(which(&b), charm.Statement("string", func(q rune) charm.State {
-		return n.notifyRune(q, String, b.String())
+	var doc *charmed.Heredoc
+	if n.UseHeredocs {
+		doc = new(charmed.Heredoc)
+	}
+	next, _ := charmed.DecodeHeredoc(quote, &b, doc)
+	return charm.Step(next, charm.Statement("string", func(q rune) charm.State {
+		var v any = b.String()
+		if doc != nil && doc.Style != 0 {
+			doc.Text = b.String()
+			v = *doc
+		}
+		return n.notifyRune(q, String, v)
 	}))
 }
 
@@ -133,18 +184,12 @@ func (n *tokenizer) decodeQuote(which quoteParser) charm.State {
 // and `false:` would match `false` until the colon.
 func (n *tokenizer) wordDecoder() charm.State {
 	return charm.Statement("wordDecoder", func(q rune) (ret charm.State) {
-		var b boolValue
-		if q == 't' {
-			b = boolTrue
-		} else if q == 'f' {
-			b = boolFalse
-		}
-		if b == boolInvalid {
+		if word, tokenType, val := n.literal(q); len(word) == 0 {
 			ret = n.decodeSignature()
 		} else {
 			var sig Signature
 			sign := sig.Decoder()
-			boolean := charmed.StringMatch(b.String())
+			boolean := charmed.StringMatch(word)
 			ret = charm.Self("parallel", func(self charm.State, q rune) (ret charm.State) {
 				ret = self
 				// sign succeeds and turns nil on whitespace after a colon;
@@ -154,11 +199,11 @@ func (n *tokenizer) wordDecoder() charm.State {
 				} else if boolean = boolean.NewRune(q); boolean == nil {
 					// boolean shouldnt match: ex. "falsey"
 					if !runes.IsWhitespace(q) {
-						boolean = charm.Error(errors.New("not a boolean"))
+						boolean = charm.Error(fmt.Errorf("not %s", word))
 					} else {
 						// note: this means a key "true true:" will be interpreted as
 						// a bool (true) followed by a key (true:)
-						ret = n.notifyRune(q, Bool, b == boolTrue)
+						ret = n.notifyRune(q, tokenType, val)
 					}
 				} else if terminal(sign) {
 					// sign is mostly superset of bool; (except for the eof/eol cases)
@@ -172,6 +217,25 @@ func (n *tokenizer) wordDecoder() charm.State {
 	})
 }
 
+// words which are values, rather than the start of a key.
+// returns an empty string if the passed rune doesn't start one.
+func (cfg *Tokenizer) literal(q rune) (word string, tokenType Type, val any) {
+	switch {
+	case q == 't':
+		word, tokenType, val = boolTrue.String(), Bool, true
+	case q == 'f':
+		word, tokenType, val = boolFalse.String(), Bool, false
+	case q == 'i' && cfg.UseInfNaN:
+		word, tokenType, val = "inf", Number, math.Inf(1)
+	case q == 'n' && cfg.UseInfNaN:
+		word, tokenType, val = "nan", Number, math.NaN()
+	}
+	if tokenType == Number && cfg.NumberMode == charmed.NumberText {
+		val = charmed.Number(word)
+	}
+	return
+}
+
 // negative numbers or sequences
 func (n *tokenizer) decodeSignature() charm.State {
 	var sig Signature
@@ -217,25 +281,34 @@ func (n *tokenizer) commentDecoder() charm.State {
 // fix? returns float64 because json does
 // could also return int64 when its int like
 func (n *tokenizer) numDecoder() charm.State {
-	var d charmed.NumParser
+	d := charmed.NumParser{AllowInf: n.UseInfNaN}
 	return charm.Step(d.Decode(), charm.Statement("numDecoder", func(q rune) (ret charm.State) {
-		if n.UseFloats {
-			if v, e := d.GetFloat(); e != nil {
-				ret = charm.Error(e)
-			} else {
-				ret = n.notifyRune(q, Number, v)
-			}
+		if v, e := n.numValue(&d); e != nil {
+			ret = charm.Error(e)
 		} else {
-			if v, e := d.GetNumber(); e != nil {
-				ret = charm.Error(e)
-			} else {
-				ret = n.notifyRune(q, Number, v)
-			}
+			ret = n.notifyRune(q, Number, v)
 		}
 		return
 	}))
 }
 
+// generate a value from a successfully parsed number.
+func (cfg *Tokenizer) numValue(d *charmed.NumParser) (ret any, err error) {
+	switch big := cfg.NumberMode == charmed.NumberBig; {
+	case cfg.NumberMode == charmed.NumberText:
+		ret, err = d.GetText()
+	case cfg.UseFloats && big:
+		ret, err = d.GetBigFloat()
+	case cfg.UseFloats:
+		ret, err = d.GetFloat()
+	case big:
+		ret, err = d.GetBigNumber()
+	default:
+		ret, err = d.GetNumber()
+	}
+	return
+}
+
 // a tri-boolean: 0 is invalid, not false.
 type boolValue int
 
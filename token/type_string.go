@@ -15,11 +15,12 @@ func _() {
 	_ = x[Key-4]
 	_ = x[Number-5]
 	_ = x[String-6]
+	_ = x[Custom-7]
 }
 
-const _Type_name = "InvalidArrayBoolCommentKeyNumberString"
+const _Type_name = "InvalidArrayBoolCommentKeyNumberStringCustom"
 
-var _Type_index = [...]uint8{0, 7, 12, 16, 23, 26, 32, 38}
+var _Type_index = [...]uint8{0, 7, 12, 16, 23, 26, 32, 38, 44}
 
 func (i Type) String() string {
 	if i < 0 || i >= Type(len(_Type_index)-1) {